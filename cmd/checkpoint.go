@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cap-ai/cap/internal/checkpoint"
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "List, diff and restore the git checkpoints of a session",
+	Long: `Checkpoints are snapshots of the git working tree recorded after each agent
+turn that changes files, when "checkpoints" is enabled in the config. They are
+kept as commits under refs/cap/<session-id> and also cover files changed by
+the bash tool.`,
+}
+
+var checkpointListCmd = &cobra.Command{
+	Use:   "list <session-id>",
+	Short: "List the checkpoints of a session, newest first",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := openCheckpointRepo(cmd)
+		if err != nil {
+			return err
+		}
+		checkpoints, err := repo.List(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		for _, cp := range checkpoints {
+			fmt.Printf("%s\t%s\t%s\t%s\n", cp.Commit[:12], time.Unix(cp.CreatedAt, 0).Format("2006-01-02 15:04:05"), cp.MessageID, cp.Subject)
+		}
+		return nil
+	},
+}
+
+var checkpointDiffCmd = &cobra.Command{
+	Use:   "diff <session-id> <checkpoint>",
+	Short: "Show the changes recorded by a checkpoint",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := openCheckpointRepo(cmd)
+		if err != nil {
+			return err
+		}
+		cp, err := repo.Find(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		patch, err := repo.Diff(cmd.Context(), cp)
+		if err != nil {
+			return err
+		}
+		fmt.Print(patch)
+		return nil
+	},
+}
+
+var checkpointRestoreCmd = &cobra.Command{
+	Use:   "restore <session-id> <checkpoint>",
+	Short: "Restore the working tree to a checkpoint",
+	Long: `Restore the working tree to a checkpoint. Files that did not exist at the
+checkpoint are removed. The current state is recorded as a new checkpoint
+first, so the restore can be undone.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := openCheckpointRepo(cmd)
+		if err != nil {
+			return err
+		}
+		cp, err := repo.Find(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := repo.Restore(cmd.Context(), cp); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s to %s\n", repo.Root(), cp.Commit[:12])
+		return nil
+	},
+}
+
+// openCheckpointRepo loads the config and opens the git repository of the
+// working directory.
+func openCheckpointRepo(cmd *cobra.Command) (*checkpoint.Repo, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	cwd, _ := cmd.Flags().GetString("cwd")
+	if cwd != "" {
+		if err := os.Chdir(cwd); err != nil {
+			return nil, fmt.Errorf("failed to change directory: %v", err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	cfg, err := config.Load(cwd, debug)
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint.Open(cmd.Context(), cwd, cfg.Data.Directory)
+}
+
+func init() {
+	checkpointCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	checkpointCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
+
+	checkpointCmd.AddCommand(checkpointListCmd, checkpointDiffCmd, checkpointRestoreCmd)
+	rootCmd.AddCommand(checkpointCmd)
+}
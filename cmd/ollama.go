@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/spf13/cobra"
+)
+
+var ollamaCmd = &cobra.Command{
+	Use:   "ollama",
+	Short: "List and pull the models of the Ollama server",
+	Long: `Manage the models of the Ollama server configured as the "ollama" provider.
+The endpoint is taken from the config, then OLLAMA_HOST, then
+http://localhost:11434.`,
+}
+
+var ollamaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the installed models with their context length and capabilities",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		endpoint, err := ollamaEndpoint(cmd)
+		if err != nil {
+			return err
+		}
+		infos, err := models.ListOllamaModels(cmd.Context(), endpoint)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			fmt.Printf("ollama.%s\t%d\t%s\n", info.Name, info.ContextLength, strings.Join(info.Capabilities, ","))
+		}
+		return nil
+	},
+}
+
+var ollamaPullCmd = &cobra.Command{
+	Use:   "pull <model>",
+	Short: "Download a model to the Ollama server",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		endpoint, err := ollamaEndpoint(cmd)
+		if err != nil {
+			return err
+		}
+		last := ""
+		err = models.PullOllamaModel(cmd.Context(), endpoint, args[0], func(p models.OllamaPullProgress) {
+			line := p.Status
+			if p.Total > 0 {
+				line = fmt.Sprintf("%s %d%%", p.Status, p.Completed*100/p.Total)
+			}
+			if line != last {
+				fmt.Fprintln(os.Stderr, line)
+				last = line
+			}
+		})
+		if err != nil {
+			return err
+		}
+		info, err := models.ShowOllamaModel(cmd.Context(), endpoint, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ollama.%s\t%d\t%s\n", info.Name, info.ContextLength, strings.Join(info.Capabilities, ","))
+		return nil
+	},
+}
+
+// ollamaEndpoint loads the config and returns the endpoint of the ollama
+// provider.
+func ollamaEndpoint(cmd *cobra.Command) (string, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	cwd, _ := cmd.Flags().GetString("cwd")
+	if cwd != "" {
+		if err := os.Chdir(cwd); err != nil {
+			return "", fmt.Errorf("failed to change directory: %v", err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	cfg, err := config.Load(cwd, debug)
+	if err != nil {
+		return "", err
+	}
+	return models.OllamaEndpoint(cfg.Providers[models.ProviderOllama].Endpoint), nil
+}
+
+func init() {
+	ollamaCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	ollamaCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
+
+	ollamaCmd.AddCommand(ollamaListCmd, ollamaPullCmd)
+	rootCmd.AddCommand(ollamaCmd)
+}
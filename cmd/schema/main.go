@@ -77,6 +77,12 @@ func generateSchema() map[string]any {
 		"default":     false,
 	}
 
+	schema["properties"].(map[string]any)["checkpoints"] = map[string]any{
+		"type":        "boolean",
+		"description": "Snapshot the git working tree into refs/cap/<session> after each agent turn that changes files",
+		"default":     false,
+	}
+
 	schema["properties"].(map[string]any)["contextPaths"] = map[string]any{
 		"type":        "array",
 		"description": "Context paths for the application",
@@ -191,6 +197,24 @@ func generateSchema() map[string]any {
 					"type":        "string",
 					"description": "Base URL for the provider (e.g. http://localhost:11434/v1)",
 				},
+				"keepAlive": map[string]any{
+					"type":        "string",
+					"description": "How long Ollama keeps the model loaded after a request (e.g. 30m)",
+				},
+				"apiKeyEnv": map[string]any{
+					"type":        "string",
+					"description": "Environment variable holding the API key, used when apiKey is not set",
+				},
+				"headers": map[string]any{
+					"type":                 "object",
+					"description":          "Headers sent with every request to a custom OpenAI compatible provider",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+				"numCtx": map[string]any{
+					"type":        "integer",
+					"description": "Context length Ollama loads the model with",
+					"minimum":     1,
+				},
 			},
 		},
 	}
@@ -205,6 +229,8 @@ func generateSchema() map[string]any {
 		string(models.ProviderBedrock),
 		string(models.ProviderAzure),
 		string(models.ProviderVertexAI),
+		string(models.ProviderOllama),
+		string(models.ProviderLlamaCpp),
 	}
 
 	providerSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["provider"] = map[string]any{
@@ -215,6 +241,67 @@ func generateSchema() map[string]any {
 
 	schema["properties"].(map[string]any)["providers"] = providerSchema
 
+	schema["properties"].(map[string]any)["models"] = map[string]any{
+		"type":        "array",
+		"description": "Models added to the built-in ones, or replacing them when the ID is the same. Providers that are not built in are OpenAI compatible endpoints.",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id": map[string]any{
+					"type":        "string",
+					"description": "Model ID used in agent configurations",
+				},
+				"name": map[string]any{
+					"type":        "string",
+					"description": "Display name",
+				},
+				"provider": map[string]any{
+					"type":        "string",
+					"description": "Provider serving the model",
+				},
+				"apiModel": map[string]any{
+					"type":        "string",
+					"description": "Model name sent to the provider API",
+				},
+				"contextWindow": map[string]any{
+					"type":        "integer",
+					"description": "Context window in tokens",
+					"minimum":     1,
+				},
+				"defaultMaxTokens": map[string]any{
+					"type":        "integer",
+					"description": "Default maximum output tokens",
+					"minimum":     1,
+				},
+				"costPer1MIn": map[string]any{
+					"type":        "number",
+					"description": "Cost per million input tokens",
+				},
+				"costPer1MOut": map[string]any{
+					"type":        "number",
+					"description": "Cost per million output tokens",
+				},
+				"costPer1MInCached": map[string]any{
+					"type":        "number",
+					"description": "Cost per million tokens written to the prompt cache",
+				},
+				"costPer1MOutCached": map[string]any{
+					"type":        "number",
+					"description": "Cost per million tokens read from the prompt cache",
+				},
+				"canReason": map[string]any{
+					"type":        "boolean",
+					"description": "Whether the model supports reasoning",
+				},
+				"supportsAttachments": map[string]any{
+					"type":        "boolean",
+					"description": "Whether the model accepts image attachments",
+				},
+			},
+			"required": []string{"id", "provider", "apiModel", "contextWindow"},
+		},
+	}
+
 	// Add agents
 	agentSchema := map[string]any{
 		"type":        "object",
@@ -237,17 +324,26 @@ func generateSchema() map[string]any {
 					"description": "Reasoning effort for models that support it (OpenAI, Anthropic)",
 					"enum":        []string{"low", "medium", "high"},
 				},
+				"fallbacks": map[string]any{
+					"type":        "array",
+					"description": "Models tried in order when the model's provider fails with auth, quota or outage errors",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
 			},
 			"required": []string{"model"},
 		},
 	}
 
-	// Add model enum
+	// Add built-in model IDs as examples, since models can also be declared
+	// in the config or discovered on local servers
 	modelEnum := []string{}
 	for modelID := range models.SupportedModels {
 		modelEnum = append(modelEnum, string(modelID))
 	}
-	agentSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["model"].(map[string]any)["enum"] = modelEnum
+	agentSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["model"].(map[string]any)["examples"] = modelEnum
+	agentSchema["additionalProperties"].(map[string]any)["properties"].(map[string]any)["fallbacks"].(map[string]any)["items"].(map[string]any)["examples"] = modelEnum
 
 	// Add specific agent properties
 	agentProperties := map[string]any{}
@@ -309,5 +405,44 @@ func generateSchema() map[string]any {
 		},
 	}
 
+	// Add permission rules
+	schema["properties"].(map[string]any)["permissions"] = map[string]any{
+		"type":        "object",
+		"description": "Rules evaluated before asking the user for permission",
+		"properties": map[string]any{
+			"rules": map[string]any{
+				"type":        "array",
+				"description": "Permission rules. When several rules match, deny wins over ask and ask wins over allow",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"tool": map[string]any{
+							"type":        "string",
+							"description": "Tool name (bash, edit, write, patch, fetch or an MCP tool)",
+						},
+						"action": map[string]any{
+							"type":        "string",
+							"description": "Action requested by the tool (execute, write, create, update, delete, fetch)",
+						},
+						"path": map[string]any{
+							"type":        "string",
+							"description": "Path glob relative to the working directory",
+						},
+						"command": map[string]any{
+							"type":        "string",
+							"description": "Bash command pattern where * matches anything",
+						},
+						"decision": map[string]any{
+							"type":        "string",
+							"description": "Decision for matching requests",
+							"enum":        []string{"allow", "deny", "ask"},
+						},
+					},
+					"required": []string{"decision"},
+				},
+			},
+		},
+	}
+
 	return schema
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cap-ai/cap/internal/app"
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/db"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run CAP as a local HTTP API server",
+	Long: `Run CAP without the TUI and expose sessions, messages, agent runs and
+permission requests over a local HTTP API. Service events are streamed with
+Server-Sent Events from /events.`,
+	Example: `
+  # Serve on the default address
+  cap serve
+
+  # Serve on another port and require a bearer token
+  cap serve --addr 127.0.0.1:9000 --token secret
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		debug, _ := cmd.Flags().GetBool("debug")
+		cwd, _ := cmd.Flags().GetString("cwd")
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv("CAP_SERVE_TOKEN")
+		}
+
+		if cwd != "" {
+			err := os.Chdir(cwd)
+			if err != nil {
+				return fmt.Errorf("failed to change directory: %v", err)
+			}
+		}
+		if cwd == "" {
+			cwd = "./"
+		}
+		_, err := config.Load(cwd, debug)
+		if err != nil {
+			return err
+		}
+
+		// Connect DB, this will also run migrations
+		conn, err := db.Connect()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		app, err := app.New(ctx, conn)
+		if err != nil {
+			logging.Error("Failed to create app: %v", err)
+			return err
+		}
+		defer app.Shutdown()
+
+		initMCPTools(ctx, app)
+
+		fmt.Fprintf(os.Stderr, "CAP API server listening on http://%s\n", addr)
+		return server.New(ctx, app, token).ListenAndServe(ctx, addr)
+	},
+}
+
+func init() {
+	serveCmd.Flags().BoolP("debug", "d", false, "Debug")
+	serveCmd.Flags().StringP("cwd", "c", "", "Current working directory")
+	serveCmd.Flags().String("addr", "127.0.0.1:7777", "Address to listen on")
+	serveCmd.Flags().String("token", "", "Bearer token required by the API (defaults to $CAP_SERVE_TOKEN)")
+	rootCmd.AddCommand(serveCmd)
+}
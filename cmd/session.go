@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/db"
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/transcript"
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage sessions stored in the database",
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := openTranscriptServices(cmd)
+		if err != nil {
+			return err
+		}
+		sessions, err := svc.Sessions.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for _, sess := range sessions {
+			fmt.Printf("%s\t%d messages\t$%.4f\t%s\n", sess.ID, sess.MessageCount, sess.Cost, sess.Title)
+		}
+		return nil
+	},
+}
+
+var sessionExportCmd = &cobra.Command{
+	Use:   "export <session-id>",
+	Short: "Export a session as Markdown or as a JSON bundle",
+	Example: `
+  # Attach a transcript to a code review
+  cap session export <session-id> --format md -o transcript.md
+
+  # Move a session to another machine
+  cap session export <session-id> --format json -o session.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		svc, err := openTranscriptServices(cmd)
+		if err != nil {
+			return err
+		}
+		bundle, err := transcript.Export(cmd.Context(), svc, args[0])
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		return transcript.Write(w, bundle, transcript.Format(format))
+	},
+}
+
+var sessionImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a session from a JSON bundle (use - for stdin)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open bundle: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		bundle, err := transcript.Read(r)
+		if err != nil {
+			return err
+		}
+
+		svc, err := openTranscriptServices(cmd)
+		if err != nil {
+			return err
+		}
+		sess, err := transcript.Import(cmd.Context(), svc, bundle)
+		if err != nil {
+			return err
+		}
+		fmt.Println(sess.ID)
+		return nil
+	},
+}
+
+var sessionForkCmd = &cobra.Command{
+	Use:   "fork <session-id> <message-id>",
+	Short: "Copy a session up to a message into a new session",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := openTranscriptServices(cmd)
+		if err != nil {
+			return err
+		}
+		sess, err := transcript.Fork(cmd.Context(), svc, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(sess.ID)
+		return nil
+	},
+}
+
+// openTranscriptServices loads the config and opens the services backed by the
+// database without starting the agent, LSP clients or MCP servers.
+func openTranscriptServices(cmd *cobra.Command) (transcript.Services, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	cwd, _ := cmd.Flags().GetString("cwd")
+	if cwd != "" {
+		if err := os.Chdir(cwd); err != nil {
+			return transcript.Services{}, fmt.Errorf("failed to change directory: %v", err)
+		}
+	} else {
+		cwd = "./"
+	}
+	if _, err := config.Load(cwd, debug); err != nil {
+		return transcript.Services{}, err
+	}
+	conn, err := db.Connect()
+	if err != nil {
+		return transcript.Services{}, err
+	}
+	q := db.New(conn)
+	return transcript.Services{
+		Sessions: session.NewService(q),
+		Messages: message.NewService(q),
+		History:  history.NewService(q, conn),
+	}, nil
+}
+
+func init() {
+	sessionCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	sessionCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
+	sessionExportCmd.Flags().StringP("format", "f", string(transcript.FormatMarkdown), "Export format (md, json)")
+	sessionExportCmd.Flags().StringP("output", "o", "", "Output file (defaults to stdout)")
+
+	sessionCmd.AddCommand(sessionListCmd, sessionExportCmd, sessionImportCmd, sessionForkCmd)
+	rootCmd.AddCommand(sessionCmd)
+}
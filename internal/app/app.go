@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"os"
 	"sync"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/cap-ai/cap/internal/message"
 	"github.com/cap-ai/cap/internal/permission"
 	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
 	"github.com/cap-ai/cap/internal/tui/theme"
 )
 
@@ -27,6 +29,7 @@ type App struct {
 	Messages    message.Service
 	History     history.Service
 	Permissions permission.Service
+	Tasks       task.Service
 
 	CoderAgent agent.Service
 
@@ -49,7 +52,8 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(),
+		Permissions: permission.NewPermissionService(q),
+		Tasks:       task.NewService(q),
 		LSPClients:  make(map[string]*lsp.Client),
 	}
 
@@ -69,6 +73,7 @@ func New(ctx context.Context, conn *sql.DB) (*App, error) {
 			app.Sessions,
 			app.Messages,
 			app.History,
+			app.Tasks,
 			app.LSPClients,
 		),
 	)
@@ -128,6 +133,10 @@ func (a *App) RunNonInteractive(ctx context.Context, prompt string, outputFormat
 	// Automatically approve all permission requests for this non-interactive session
 	a.Permissions.AutoApproveSession(sess.ID)
 
+	if outputFormat == format.StreamJSON.String() {
+		return a.runStreamJSON(ctx, sess.ID, prompt)
+	}
+
 	done, err := a.CoderAgent.Run(ctx, sess.ID, prompt)
 	if err != nil {
 		return fmt.Errorf("failed to start agent processing stream: %w", err)
@@ -160,6 +169,35 @@ func (a *App) RunNonInteractive(ctx context.Context, prompt string, outputFormat
 	return nil
 }
 
+// runStreamJSON runs the agent and writes every event of the session to stdout
+// as NDJSON, ending with a result event.
+func (a *App) runStreamJSON(ctx context.Context, sessionID string, prompt string) error {
+	streamer := newJSONStreamer(os.Stdout, sessionID)
+	streamer.init(string(a.CoderAgent.Model().ID))
+
+	subCtx, cancelSub := context.WithCancel(ctx)
+	wait := streamer.subscribe(subCtx, a)
+
+	done, err := a.CoderAgent.Run(ctx, sessionID, prompt)
+	if err != nil {
+		cancelSub()
+		wait()
+		return fmt.Errorf("failed to start agent processing stream: %w", err)
+	}
+	result := <-done
+
+	cancelSub()
+	wait()
+	streamer.flush(context.Background(), a)
+	streamer.result(result.Message, result.Error)
+
+	logging.Info("Non-interactive run completed", "session_id", sessionID)
+	if result.Error != nil && !errors.Is(result.Error, context.Canceled) && !errors.Is(result.Error, agent.ErrRequestCancelled) {
+		return fmt.Errorf("agent processing failed: %w", result.Error)
+	}
+	return nil
+}
+
 // Shutdown performs a clean shutdown of the application
 func (app *App) Shutdown() {
 	// Cancel all watcher goroutines
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/permission"
+	"github.com/cap-ai/cap/internal/session"
+)
+
+// Stream event types written by the stream-json output format.
+const (
+	streamEventInit           = "init"
+	streamEventReasoningDelta = "reasoning_delta"
+	streamEventTextDelta      = "text_delta"
+	streamEventToolCall       = "tool_call"
+	streamEventToolResult     = "tool_result"
+	streamEventPermission     = "permission"
+	streamEventUsage          = "usage"
+	streamEventResult         = "result"
+)
+
+type streamUsage struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+type streamEvent struct {
+	Type         string                             `json:"type"`
+	SessionID    string                             `json:"session_id"`
+	MessageID    string                             `json:"message_id,omitempty"`
+	Model        string                             `json:"model,omitempty"`
+	Text         string                             `json:"text,omitempty"`
+	ToolCall     *message.ToolCall                  `json:"tool_call,omitempty"`
+	ToolResult   *message.ToolResult                `json:"tool_result,omitempty"`
+	Permission   *permission.PermissionNotification `json:"permission,omitempty"`
+	Usage        *streamUsage                       `json:"usage,omitempty"`
+	Result       string                             `json:"result,omitempty"`
+	FinishReason message.FinishReason               `json:"finish_reason,omitempty"`
+	IsError      bool                               `json:"is_error,omitempty"`
+	Error        string                             `json:"error,omitempty"`
+}
+
+// jsonStreamer turns service events of a single session into NDJSON lines.
+// Messages arrive as full snapshots, so it remembers what was already written
+// and only emits the new part.
+type jsonStreamer struct {
+	mu          sync.Mutex
+	enc         *json.Encoder
+	sessionID   string
+	texts       map[string]int
+	reasoning   map[string]int
+	toolCalls   map[string]bool
+	toolResults map[string]bool
+	usage       streamUsage
+}
+
+func newJSONStreamer(w io.Writer, sessionID string) *jsonStreamer {
+	return &jsonStreamer{
+		enc:         json.NewEncoder(w),
+		sessionID:   sessionID,
+		texts:       make(map[string]int),
+		reasoning:   make(map[string]int),
+		toolCalls:   make(map[string]bool),
+		toolResults: make(map[string]bool),
+	}
+}
+
+func (s *jsonStreamer) write(event streamEvent) {
+	event.SessionID = s.sessionID
+	if err := s.enc.Encode(event); err != nil {
+		logging.Error("failed to write stream event", "error", err)
+	}
+}
+
+func (s *jsonStreamer) init(model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(streamEvent{Type: streamEventInit, Model: model})
+}
+
+// handleMessage writes the content added to the message since the last call.
+// When final is set, tool calls are written even if the message is not finished.
+func (s *jsonStreamer) handleMessage(msg message.Message, final bool) {
+	if msg.SessionID != s.sessionID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reasoning := msg.ReasoningContent().Thinking; len(reasoning) > s.reasoning[msg.ID] {
+		s.write(streamEvent{Type: streamEventReasoningDelta, MessageID: msg.ID, Text: reasoning[s.reasoning[msg.ID]:]})
+		s.reasoning[msg.ID] = len(reasoning)
+	}
+	if msg.Role == message.Assistant {
+		if text := msg.Content().Text; len(text) > s.texts[msg.ID] {
+			s.write(streamEvent{Type: streamEventTextDelta, MessageID: msg.ID, Text: text[s.texts[msg.ID]:]})
+			s.texts[msg.ID] = len(text)
+		}
+	}
+	// Tool call inputs are complete only once the message is finished.
+	for _, call := range msg.ToolCalls() {
+		if s.toolCalls[call.ID] || (!msg.IsFinished() && !final) {
+			continue
+		}
+		s.toolCalls[call.ID] = true
+		s.write(streamEvent{Type: streamEventToolCall, MessageID: msg.ID, ToolCall: &call})
+	}
+	for _, result := range msg.ToolResults() {
+		if s.toolResults[result.ToolCallID] {
+			continue
+		}
+		s.toolResults[result.ToolCallID] = true
+		s.write(streamEvent{Type: streamEventToolResult, MessageID: msg.ID, ToolResult: &result})
+	}
+}
+
+func (s *jsonStreamer) handleSession(sess session.Session) {
+	if sess.ID != s.sessionID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := streamUsage{
+		PromptTokens:     sess.PromptTokens,
+		CompletionTokens: sess.CompletionTokens,
+		Cost:             sess.Cost,
+	}
+	if usage == s.usage {
+		return
+	}
+	s.usage = usage
+	s.write(streamEvent{Type: streamEventUsage, Usage: &usage})
+}
+
+func (s *jsonStreamer) handlePermission(notification permission.PermissionNotification) {
+	if notification.SessionID != s.sessionID {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(streamEvent{Type: streamEventPermission, Permission: &notification})
+}
+
+func (s *jsonStreamer) result(msg message.Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := streamEvent{
+		Type:         streamEventResult,
+		MessageID:    msg.ID,
+		Result:       msg.Content().Text,
+		FinishReason: msg.FinishReason(),
+		Usage:        &s.usage,
+	}
+	if err != nil {
+		event.IsError = true
+		event.Error = err.Error()
+	}
+	s.write(event)
+}
+
+// subscribe forwards the app events to the streamer until ctx is done. The
+// returned function waits for the forwarding goroutine to stop.
+func (s *jsonStreamer) subscribe(ctx context.Context, a *App) func() {
+	messages := a.Messages.Subscribe(ctx)
+	sessions := a.Sessions.Subscribe(ctx)
+	permissions := a.Permissions.SubscribeNotifications(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer logging.RecoverPanic("stream-json", nil)
+		// The broker closes the channels once ctx is done, after the buffered
+		// events have been read.
+		for messages != nil || sessions != nil || permissions != nil {
+			select {
+			case event, ok := <-messages:
+				if !ok {
+					messages = nil
+					continue
+				}
+				s.handleMessage(event.Payload, false)
+			case event, ok := <-sessions:
+				if !ok {
+					sessions = nil
+					continue
+				}
+				s.handleSession(event.Payload)
+			case event, ok := <-permissions:
+				if !ok {
+					permissions = nil
+					continue
+				}
+				s.handlePermission(event.Payload)
+			}
+		}
+	}()
+	return wg.Wait
+}
+
+// flush writes whatever the subscription missed, using the stored messages
+// and session as the source of truth.
+func (s *jsonStreamer) flush(ctx context.Context, a *App) {
+	msgs, err := a.Messages.List(ctx, s.sessionID)
+	if err != nil {
+		logging.Error("failed to list messages for stream", "error", err)
+	}
+	for _, msg := range msgs {
+		s.handleMessage(msg, true)
+	}
+	sess, err := a.Sessions.Get(ctx, s.sessionID)
+	if err != nil {
+		logging.Error("failed to get session for stream", "error", err)
+		return
+	}
+	s.handleSession(sess)
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONStreamer_WritesOnlyNewContent(t *testing.T) {
+	var buf bytes.Buffer
+	s := newJSONStreamer(&buf, "session-1")
+
+	msg := message.Message{ID: "m1", SessionID: "session-1", Role: message.Assistant}
+	msg.AppendContent("Hello")
+	s.handleMessage(msg, false)
+	msg.AppendContent(", world")
+	msg.AddToolCall(message.ToolCall{ID: "call-1", Name: "bash"})
+	s.handleMessage(msg, false)
+	msg.SetToolCalls([]message.ToolCall{{ID: "call-1", Name: "bash", Input: `{"command":"ls"}`, Finished: true}})
+	msg.AddFinish(message.FinishReasonToolUse)
+	s.handleMessage(msg, false)
+	s.handleMessage(msg, true)
+
+	other := message.Message{ID: "m2", SessionID: "session-2", Role: message.Assistant}
+	other.AppendContent("ignored")
+	s.handleMessage(other, true)
+
+	var events []streamEvent
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e streamEvent
+		require.NoError(t, dec.Decode(&e))
+		events = append(events, e)
+	}
+
+	require.Len(t, events, 3)
+	assert.Equal(t, streamEventTextDelta, events[0].Type)
+	assert.Equal(t, "Hello", events[0].Text)
+	assert.Equal(t, ", world", events[1].Text)
+	assert.Equal(t, streamEventToolCall, events[2].Type)
+	assert.Equal(t, `{"command":"ls"}`, events[2].ToolCall.Input)
+}
@@ -0,0 +1,319 @@
+// Package checkpoint snapshots the working tree into git commits kept under
+// refs/cap/<session-id>. Snapshots are built with a private index file, so the
+// user's index, HEAD and branches are never touched.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	refPrefix      = "refs/cap/"
+	messageTrailer = "Cap-Message"
+	sessionTrailer = "Cap-Session"
+)
+
+// ErrNotFound is returned when a checkpoint does not belong to the session.
+var ErrNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is a snapshot of the working tree.
+type Checkpoint struct {
+	Commit    string
+	Tree      string
+	SessionID string
+	// MessageID is the user message of the agent turn that made the changes.
+	// It is empty for snapshots of changes made outside the agent.
+	MessageID string
+	Subject   string
+	CreatedAt int64
+}
+
+// Repo is the git repository checkpoints are written to.
+type Repo struct {
+	root    string
+	exclude string
+}
+
+// Open finds the git repository containing dir. Files under dataDir are left
+// out of the snapshots.
+func Open(ctx context.Context, dir, dataDir string) (*Repo, error) {
+	out, err := runGit(ctx, dir, nil, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	repo := &Repo{root: strings.TrimSpace(out)}
+	if dataDir != "" {
+		if !filepath.IsAbs(dataDir) {
+			dataDir = filepath.Join(dir, dataDir)
+		}
+		if abs, err := filepath.Abs(dataDir); err == nil {
+			dataDir = abs
+		}
+		if rel, err := filepath.Rel(repo.root, dataDir); err == nil && !strings.HasPrefix(rel, "..") {
+			repo.exclude = filepath.ToSlash(rel)
+		}
+	}
+	return repo, nil
+}
+
+// Root returns the top level directory of the repository.
+func (r *Repo) Root() string {
+	return r.root
+}
+
+func ref(sessionID string) string {
+	return refPrefix + sessionID
+}
+
+func (r *Repo) git(ctx context.Context, env []string, stdin io.Reader, args ...string) (string, error) {
+	return runGit(ctx, r.root, env, stdin, args...)
+}
+
+func runGit(ctx context.Context, dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// withIndex runs fn with a private index file, seeded from the user's index so
+// that unchanged files do not have to be hashed again.
+func (r *Repo) withIndex(ctx context.Context, fn func(env []string) error) error {
+	tmp, err := os.MkdirTemp("", "cap-checkpoint-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+
+	out, err := r.git(ctx, nil, nil, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return err
+	}
+	userIndex := strings.TrimSpace(out)
+	if !filepath.IsAbs(userIndex) {
+		userIndex = filepath.Join(r.root, userIndex)
+	}
+	if content, err := os.ReadFile(userIndex); err == nil {
+		if err := os.WriteFile(index, content, 0o600); err != nil {
+			return err
+		}
+	}
+	return fn([]string{"GIT_INDEX_FILE=" + index})
+}
+
+func (r *Repo) pathspec() []string {
+	spec := []string{"--", "."}
+	if r.exclude != "" {
+		spec = append(spec, ":(exclude)"+r.exclude)
+	}
+	return spec
+}
+
+// WriteTree stores the current working tree, including untracked files that
+// are not ignored, and returns its tree hash.
+func (r *Repo) WriteTree(ctx context.Context) (string, error) {
+	var tree string
+	err := r.withIndex(ctx, func(env []string) error {
+		if _, err := r.git(ctx, env, nil, append([]string{"add", "-A"}, r.pathspec()...)...); err != nil {
+			return err
+		}
+		out, err := r.git(ctx, env, nil, "write-tree")
+		tree = strings.TrimSpace(out)
+		return err
+	})
+	return tree, err
+}
+
+// Tip returns the latest checkpoint of the session. It returns false when the
+// session has none.
+func (r *Repo) Tip(ctx context.Context, sessionID string) (Checkpoint, bool, error) {
+	if _, err := r.git(ctx, nil, nil, "rev-parse", "--verify", "--quiet", ref(sessionID)); err != nil {
+		return Checkpoint{}, false, nil
+	}
+	checkpoints, err := r.log(ctx, sessionID, "-1")
+	if err != nil || len(checkpoints) == 0 {
+		return Checkpoint{}, false, err
+	}
+	return checkpoints[0], true, nil
+}
+
+// Commit records the tree as the new latest checkpoint of the session.
+func (r *Repo) Commit(ctx context.Context, sessionID, tree, messageID, subject string) (Checkpoint, error) {
+	args := []string{"commit-tree", tree}
+	tip, ok, err := r.Tip(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if ok {
+		args = append(args, "-p", tip.Commit)
+	}
+
+	body := fmt.Sprintf("%s\n\n%s: %s\n", subject, sessionTrailer, sessionID)
+	if messageID != "" {
+		body += fmt.Sprintf("%s: %s\n", messageTrailer, messageID)
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=cap",
+		"GIT_AUTHOR_EMAIL=cap@localhost",
+		"GIT_COMMITTER_NAME=cap",
+		"GIT_COMMITTER_EMAIL=cap@localhost",
+	}
+	out, err := r.git(ctx, env, strings.NewReader(body), args...)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	commit := strings.TrimSpace(out)
+	if _, err := r.git(ctx, nil, nil, "update-ref", ref(sessionID), commit); err != nil {
+		return Checkpoint{}, err
+	}
+	tip, _, err = r.Tip(ctx, sessionID)
+	return tip, err
+}
+
+// Record creates a checkpoint for an agent turn when the working tree changed
+// since before, the tree written when the turn started. If the tree had also
+// changed since the last checkpoint before the turn, that state is recorded
+// first so the turn can be undone on its own. It returns false when nothing
+// changed.
+func (r *Repo) Record(ctx context.Context, sessionID, before, messageID, subject string) (Checkpoint, bool, error) {
+	after, err := r.WriteTree(ctx)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	if after == before {
+		return Checkpoint{}, false, nil
+	}
+	tip, ok, err := r.Tip(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	if !ok || tip.Tree != before {
+		if _, err := r.Commit(ctx, sessionID, before, "", "Before: "+subject); err != nil {
+			return Checkpoint{}, false, err
+		}
+	}
+	checkpoint, err := r.Commit(ctx, sessionID, after, messageID, subject)
+	return checkpoint, err == nil, err
+}
+
+// List returns the checkpoints of the session, newest first.
+func (r *Repo) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	if _, ok, err := r.Tip(ctx, sessionID); err != nil || !ok {
+		return nil, err
+	}
+	return r.log(ctx, sessionID)
+}
+
+func (r *Repo) log(ctx context.Context, sessionID string, args ...string) ([]Checkpoint, error) {
+	format := "--format=%H%x1f%T%x1f%ct%x1f%s%x1f%(trailers:key=" + messageTrailer + ",valueonly,separator=)%x1e"
+	out, err := r.git(ctx, nil, nil, append(append([]string{"log", format}, args...), ref(sessionID))...)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []Checkpoint
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(fields[2], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			Commit:    fields[0],
+			Tree:      fields[1],
+			SessionID: sessionID,
+			MessageID: strings.TrimSpace(fields[4]),
+			Subject:   fields[3],
+			CreatedAt: createdAt,
+		})
+	}
+	return checkpoints, nil
+}
+
+// Find returns the checkpoint of the session whose commit hash starts with
+// prefix.
+func (r *Repo) Find(ctx context.Context, sessionID, prefix string) (Checkpoint, error) {
+	checkpoints, err := r.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	var found []Checkpoint
+	for _, checkpoint := range checkpoints {
+		if prefix != "" && strings.HasPrefix(checkpoint.Commit, prefix) {
+			found = append(found, checkpoint)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Checkpoint{}, fmt.Errorf("%w: %s", ErrNotFound, prefix)
+	case 1:
+		return found[0], nil
+	default:
+		return Checkpoint{}, fmt.Errorf("checkpoint %s is ambiguous", prefix)
+	}
+}
+
+// Diff returns the changes recorded by the checkpoint, compared to the one
+// before it.
+func (r *Repo) Diff(ctx context.Context, checkpoint Checkpoint) (string, error) {
+	return r.git(ctx, nil, nil, "diff-tree", "-p", "--root", "--no-color", checkpoint.Commit)
+}
+
+// Restore makes the working tree match the checkpoint. Files that did not
+// exist then are removed. The current state is recorded as a checkpoint first,
+// so a restore can itself be undone.
+func (r *Repo) Restore(ctx context.Context, checkpoint Checkpoint) error {
+	current, err := r.WriteTree(ctx)
+	if err != nil {
+		return err
+	}
+	if current == checkpoint.Tree {
+		return nil
+	}
+	tip, ok, err := r.Tip(ctx, checkpoint.SessionID)
+	if err != nil {
+		return err
+	}
+	if !ok || tip.Tree != current {
+		if _, err := r.Commit(ctx, checkpoint.SessionID, current, "", "Before restoring "+checkpoint.Commit[:12]); err != nil {
+			return err
+		}
+	}
+
+	out, err := r.git(ctx, nil, nil, "diff-tree", "-r", "-z", "--name-only", "--no-renames", "--diff-filter=D", current, checkpoint.Tree)
+	if err != nil {
+		return err
+	}
+	for _, path := range strings.Split(out, "\x00") {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.root, filepath.FromSlash(path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	return r.withIndex(ctx, func(env []string) error {
+		if _, err := r.git(ctx, env, nil, "read-tree", checkpoint.Tree); err != nil {
+			return err
+		}
+		_, err := r.git(ctx, env, nil, "checkout-index", "-a", "-f")
+		return err
+	})
+}
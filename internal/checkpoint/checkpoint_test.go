@@ -0,0 +1,137 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := runGit(context.Background(), dir, nil, nil, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, dir, "main.go", "package main\n")
+	writeFile(t, dir, ".gitignore", "ignored.txt\n")
+	if _, err := runGit(context.Background(), dir, nil, nil, "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGit(context.Background(), dir, nil, nil, "commit", "-q", "-m", "init"); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestRecordAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := setupRepo(t)
+	repo, err := Open(ctx, dir, ".cap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := repo.WriteTree(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := repo.Record(ctx, "s1", before, "m1", "nothing"); err != nil || ok {
+		t.Fatalf("Record() without changes = %v, %v", ok, err)
+	}
+
+	// The agent edits a file, creates one and writes to the data directory.
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "pkg/new.go", "package pkg\n")
+	writeFile(t, dir, ".cap/cap.db", "data")
+	writeFile(t, dir, "ignored.txt", "ignored")
+	checkpoint, ok, err := repo.Record(ctx, "s1", before, "m1", "add main")
+	if err != nil || !ok {
+		t.Fatalf("Record() = %v, %v", ok, err)
+	}
+	if checkpoint.MessageID != "m1" || checkpoint.Subject != "add main" {
+		t.Errorf("unexpected checkpoint %+v", checkpoint)
+	}
+
+	checkpoints, err := repo.List(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 2 || checkpoints[1].Subject != "Before: add main" {
+		t.Fatalf("List() = %+v", checkpoints)
+	}
+
+	patch, err := repo.Diff(ctx, checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "pkg/new.go") || strings.Contains(patch, "cap.db") || strings.Contains(patch, "ignored.txt") {
+		t.Errorf("unexpected diff:\n%s", patch)
+	}
+
+	if err := repo.Restore(ctx, checkpoints[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dir, "main.go"); got != "package main\n" {
+		t.Errorf("main.go = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg/new.go")); !os.IsNotExist(err) {
+		t.Errorf("pkg/new.go was not removed: %v", err)
+	}
+	if got := readFile(t, dir, ".cap/cap.db"); got != "data" {
+		t.Errorf("data directory was touched: %q", got)
+	}
+
+	// Restoring is recorded, so it can be undone.
+	found, err := repo.Find(ctx, "s1", checkpoint.Commit[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Restore(ctx, found); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dir, "pkg/new.go"); got != "package pkg\n" {
+		t.Errorf("pkg/new.go = %q", got)
+	}
+
+	status, err := runGit(ctx, dir, nil, nil, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(status, "\n"), "\n") {
+		if line != "" && line[0] != ' ' && line[0] != '?' {
+			t.Errorf("the user's index was modified:\n%s", status)
+		}
+	}
+}
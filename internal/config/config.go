@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/cap-ai/cap/internal/llm/models"
 	"github.com/cap-ai/cap/internal/logging"
 	"github.com/spf13/viper"
@@ -49,6 +50,8 @@ type Agent struct {
 	Model           models.ModelID `json:"model"`
 	MaxTokens       int64          `json:"maxTokens"`
 	ReasoningEffort string         `json:"reasoningEffort"` // For openai models low,medium,heigh
+	// Fallbacks are tried in order when the model's provider is unavailable.
+	Fallbacks []models.ModelID `json:"fallbacks,omitempty"`
 }
 
 // Provider defines configuration for an LLM provider.
@@ -57,6 +60,32 @@ type Provider struct {
 	Disabled bool   `json:"disabled"`
 	// 2025.06.14 Kawata added endpoint for provider
 	Endpoint string `json:"endpoint,omitempty"`
+	// KeepAlive is how long Ollama keeps the model loaded, e.g. "30m".
+	KeepAlive string `json:"keepAlive,omitempty"`
+	// NumCtx is the context length Ollama loads the model with.
+	NumCtx int64 `json:"numCtx,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key, used
+	// when apiKey is not set.
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+	// Headers are sent with every request to an OpenAI compatible provider
+	// declared in the config.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ModelConfig declares a model that is not built in, or overrides one that is.
+type ModelConfig struct {
+	ID                  models.ModelID       `json:"id"`
+	Name                string               `json:"name,omitempty"`
+	Provider            models.ModelProvider `json:"provider"`
+	APIModel            string               `json:"apiModel"`
+	ContextWindow       int64                `json:"contextWindow"`
+	DefaultMaxTokens    int64                `json:"defaultMaxTokens,omitempty"`
+	CostPer1MIn         float64              `json:"costPer1MIn,omitempty"`
+	CostPer1MOut        float64              `json:"costPer1MOut,omitempty"`
+	CostPer1MInCached   float64              `json:"costPer1MInCached,omitempty"`
+	CostPer1MOutCached  float64              `json:"costPer1MOutCached,omitempty"`
+	CanReason           bool                 `json:"canReason,omitempty"`
+	SupportsAttachments bool                 `json:"supportsAttachments,omitempty"`
 }
 
 // Data defines storage configuration.
@@ -83,6 +112,31 @@ type ShellConfig struct {
 	Args []string `json:"args,omitempty"`
 }
 
+// PermissionDecision is the outcome of a permission rule.
+type PermissionDecision string
+
+const (
+	PermissionAllow PermissionDecision = "allow"
+	PermissionDeny  PermissionDecision = "deny"
+	PermissionAsk   PermissionDecision = "ask"
+)
+
+// PermissionRule matches permission requests. Every non-empty field must match.
+// Path is a glob relative to the working directory and Command is a bash
+// command pattern where * matches anything.
+type PermissionRule struct {
+	Tool     string             `json:"tool,omitempty"`
+	Action   string             `json:"action,omitempty"`
+	Path     string             `json:"path,omitempty"`
+	Command  string             `json:"command,omitempty"`
+	Decision PermissionDecision `json:"decision"`
+}
+
+// PermissionsConfig defines rules evaluated before the user is asked for permission.
+type PermissionsConfig struct {
+	Rules []PermissionRule `json:"rules,omitempty"`
+}
+
 // Config is the main configuration structure for the application.
 type Config struct {
 	Data         Data                              `json:"data"`
@@ -97,6 +151,9 @@ type Config struct {
 	TUI          TUIConfig                         `json:"tui"`
 	Shell        ShellConfig                       `json:"shell,omitempty"`
 	AutoCompact  bool                              `json:"autoCompact,omitempty"`
+	Checkpoints  bool                              `json:"checkpoints,omitempty"`
+	Permissions  PermissionsConfig                 `json:"permissions,omitempty"`
+	Models       []ModelConfig                     `json:"models,omitempty"`
 }
 
 // Application constants
@@ -198,6 +255,23 @@ func Load(workingDir string, debug bool) (*Config, error) {
 		endpoint := localProviderCfg.Endpoint
 		models.InitLocal(endpoint)
 	}
+	if ollamaProviderCfg, ok := cfg.Providers[models.ProviderOllama]; ok && !ollamaProviderCfg.Disabled {
+		// Ollama needs no key
+		if ollamaProviderCfg.APIKey == "" {
+			ollamaProviderCfg.APIKey = "ollama"
+			cfg.Providers[models.ProviderOllama] = ollamaProviderCfg
+		}
+		models.InitOllama(ollamaProviderCfg.Endpoint, ollamaProviderCfg.NumCtx)
+	}
+	if llamacppProviderCfg, ok := cfg.Providers[models.ProviderLlamaCpp]; ok && !llamacppProviderCfg.Disabled {
+		// The key is only needed when the server was started with --api-key
+		if llamacppProviderCfg.APIKey == "" {
+			llamacppProviderCfg.APIKey = "llamacpp"
+			cfg.Providers[models.ProviderLlamaCpp] = llamacppProviderCfg
+		}
+		models.InitLlamaCpp(llamacppProviderCfg.Endpoint, llamacppProviderCfg.APIKey)
+	}
+	loadConfiguredModels()
 
 	// Validate configuration
 	if err := Validate(); err != nil {
@@ -277,6 +351,10 @@ func setProviderDefaults() {
 		// api-key may be empty when using Entra ID credentials – that's okay
 		viper.SetDefault("providers.azure.apiKey", os.Getenv("AZURE_OPENAI_API_KEY"))
 	}
+	// The mock provider needs no key, only a fixture to replay
+	if fixture := os.Getenv("CAP_MOCK_FIXTURE"); fixture != "" {
+		viper.SetDefault(fmt.Sprintf("providers.%s.apiKey", models.ProviderMock), "dummy")
+	}
 
 	// Use this order to set the default models
 	// 1. Anthropic
@@ -445,6 +523,73 @@ func applyDefaultValues() {
 			cfg.MCPServers[k] = v
 		}
 	}
+
+	for name, providerCfg := range cfg.Providers {
+		// Providers that are not built in are OpenAI compatible endpoints
+		if !models.IsBuiltinProvider(name) {
+			models.RegisterCustomProvider(name)
+		}
+		if providerCfg.APIKey == "" && providerCfg.APIKeyEnv != "" {
+			providerCfg.APIKey = os.Getenv(providerCfg.APIKeyEnv)
+			cfg.Providers[name] = providerCfg
+		}
+	}
+}
+
+// loadConfiguredModels adds the models declared in the config to the
+// supported models. They replace built-in or discovered models with the same ID.
+func loadConfiguredModels() {
+	configured := make([]ModelConfig, 0, len(cfg.Models))
+	for i, m := range cfg.Models {
+		// Provider names are lower case once read by viper
+		m.Provider = models.ModelProvider(strings.ToLower(string(m.Provider)))
+		if err := validateModelConfig(m); err != nil {
+			logging.Warn("invalid model, ignoring", "index", i, "id", m.ID, "error", err)
+			continue
+		}
+		model := models.Model{
+			ID:                  m.ID,
+			Name:                m.Name,
+			Provider:            m.Provider,
+			APIModel:            m.APIModel,
+			CostPer1MIn:         m.CostPer1MIn,
+			CostPer1MOut:        m.CostPer1MOut,
+			CostPer1MInCached:   m.CostPer1MInCached,
+			CostPer1MOutCached:  m.CostPer1MOutCached,
+			ContextWindow:       m.ContextWindow,
+			DefaultMaxTokens:    m.DefaultMaxTokens,
+			CanReason:           m.CanReason,
+			SupportsAttachments: m.SupportsAttachments,
+		}
+		if model.Name == "" {
+			model.Name = string(model.ID)
+		}
+		if model.DefaultMaxTokens <= 0 {
+			model.DefaultMaxTokens = min(MaxTokensFallbackDefault, model.ContextWindow/2)
+		}
+		models.SupportedModels[model.ID] = model
+		configured = append(configured, m)
+	}
+	cfg.Models = configured
+}
+
+func validateModelConfig(m ModelConfig) error {
+	if m.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if m.APIModel == "" {
+		return fmt.Errorf("apiModel is required")
+	}
+	if m.ContextWindow <= 0 {
+		return fmt.Errorf("contextWindow must be positive")
+	}
+	if m.Provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if _, ok := cfg.Providers[m.Provider]; !ok && !models.IsBuiltinProvider(m.Provider) {
+		return fmt.Errorf("unknown provider %q", m.Provider)
+	}
+	return nil
 }
 
 // It validates model IDs and providers, ensuring they are supported.
@@ -462,6 +607,7 @@ func validateAgent(cfg *Config, name AgentName, agent Agent) error {
 		} else {
 			return fmt.Errorf("no valid provider available for agent %s", name)
 		}
+		validateFallbacks(cfg, name)
 		return nil
 	}
 
@@ -575,9 +721,46 @@ func validateAgent(cfg *Config, name AgentName, agent Agent) error {
 		cfg.Agents[name] = updatedAgent
 	}
 
+	validateFallbacks(cfg, name)
 	return nil
 }
 
+// validateFallbacks drops the fallback models of the agent that cannot be
+// used, so that a failover never lands on a provider that is not configured.
+func validateFallbacks(cfg *Config, name AgentName) {
+	agent := cfg.Agents[name]
+	if len(agent.Fallbacks) == 0 {
+		return
+	}
+	fallbacks := make([]models.ModelID, 0, len(agent.Fallbacks))
+	for _, id := range agent.Fallbacks {
+		model, ok := models.SupportedModels[id]
+		if !ok {
+			logging.Warn("unsupported fallback model, ignoring", "agent", name, "model", id)
+			continue
+		}
+		if id == agent.Model {
+			continue
+		}
+		providerCfg, ok := cfg.Providers[model.Provider]
+		if !ok {
+			apiKey := getProviderAPIKey(model.Provider)
+			if apiKey == "" {
+				logging.Warn("provider not configured for fallback model, ignoring", "agent", name, "model", id, "provider", model.Provider)
+				continue
+			}
+			cfg.Providers[model.Provider] = Provider{APIKey: apiKey}
+			logging.Info("added provider from environment", "provider", model.Provider)
+		} else if providerCfg.Disabled || providerCfg.APIKey == "" {
+			logging.Warn("provider is disabled or has no API key for fallback model, ignoring", "agent", name, "model", id, "provider", model.Provider)
+			continue
+		}
+		fallbacks = append(fallbacks, id)
+	}
+	agent.Fallbacks = fallbacks
+	cfg.Agents[name] = agent
+}
+
 // Validate checks if the configuration is valid and applies defaults where needed.
 func Validate() error {
 	if cfg == nil {
@@ -600,6 +783,17 @@ func Validate() error {
 		}
 	}
 
+	// Validate permission rules
+	rules := make([]PermissionRule, 0, len(cfg.Permissions.Rules))
+	for i, rule := range cfg.Permissions.Rules {
+		if err := validatePermissionRule(rule); err != nil {
+			logging.Warn("invalid permission rule, ignoring", "index", i, "error", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	cfg.Permissions.Rules = rules
+
 	// Validate LSP configurations
 	for language, lspConfig := range cfg.LSP {
 		if lspConfig.Command == "" && !lspConfig.Disabled {
@@ -612,6 +806,22 @@ func Validate() error {
 	return nil
 }
 
+// validatePermissionRule checks that a rule has a known decision and at least one matcher.
+func validatePermissionRule(rule PermissionRule) error {
+	switch rule.Decision {
+	case PermissionAllow, PermissionDeny, PermissionAsk:
+	default:
+		return fmt.Errorf("unknown decision %q", rule.Decision)
+	}
+	if rule.Tool == "" && rule.Action == "" && rule.Path == "" && rule.Command == "" {
+		return fmt.Errorf("rule must match at least one of tool, action, path or command")
+	}
+	if rule.Path != "" && !doublestar.ValidatePattern(rule.Path) {
+		return fmt.Errorf("invalid path pattern %q", rule.Path)
+	}
+	return nil
+}
+
 // getProviderAPIKey gets the API key for a provider from environment variables
 func getProviderAPIKey(provider models.ModelProvider) string {
 	switch provider {
@@ -857,6 +1067,7 @@ func UpdateAgentModel(agentName AgentName, modelID models.ModelID) error {
 		Model:           modelID,
 		MaxTokens:       maxTokens,
 		ReasoningEffort: existingAgentCfg.ReasoningEffort,
+		Fallbacks:       existingAgentCfg.Fallbacks,
 	}
 	cfg.Agents[agentName] = newAgentCfg
 
@@ -870,7 +1081,9 @@ func UpdateAgentModel(agentName AgentName, modelID models.ModelID) error {
 		if config.Agents == nil {
 			config.Agents = make(map[AgentName]Agent)
 		}
-		config.Agents[agentName] = newAgentCfg
+		fileAgentCfg := newAgentCfg
+		fileAgentCfg.Fallbacks = config.Agents[agentName].Fallbacks
+		config.Agents[agentName] = fileAgentCfg
 	})
 }
 
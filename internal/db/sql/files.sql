@@ -69,3 +69,17 @@ SELECT *
 FROM files
 WHERE is_new = 1
 ORDER BY created_at DESC;
+
+-- name: ImportFile :one
+INSERT INTO files (
+    id,
+    session_id,
+    path,
+    content,
+    version,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
@@ -27,6 +27,7 @@ RETURNING *;
 UPDATE messages
 SET
     parts = ?,
+    model = ?,
     finished_at = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
@@ -39,3 +40,18 @@ WHERE id = ?;
 -- name: DeleteSessionMessages :exec
 DELETE FROM messages
 WHERE session_id = ?;
+
+-- name: ImportMessage :one
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    finished_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
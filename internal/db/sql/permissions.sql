@@ -0,0 +1,24 @@
+-- name: CreatePermissionGrant :one
+INSERT INTO permission_grants (
+    id,
+    session_id,
+    tool_name,
+    action,
+    path,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: FindPermissionGrant :one
+SELECT *
+FROM permission_grants
+WHERE session_id = ? AND tool_name = ? AND action = ? AND path = ?
+LIMIT 1;
+
+-- name: ListPermissionGrantsBySession :many
+SELECT *
+FROM permission_grants
+WHERE session_id = ?
+ORDER BY created_at ASC;
@@ -0,0 +1,42 @@
+-- name: GetTask :one
+SELECT *
+FROM tasks
+WHERE id = ? LIMIT 1;
+
+-- name: ListTasksBySession :many
+SELECT *
+FROM tasks
+WHERE session_id = ?
+ORDER BY position ASC, created_at ASC;
+
+-- name: CreateTask :one
+INSERT INTO tasks (
+    id,
+    session_id,
+    position,
+    content,
+    status,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: UpdateTask :one
+UPDATE tasks
+SET
+    position = ?,
+    content = ?,
+    status = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteTask :exec
+DELETE FROM tasks
+WHERE id = ?;
+
+-- name: DeleteSessionTasks :exec
+DELETE FROM tasks
+WHERE session_id = ?;
@@ -15,6 +15,9 @@ const (
 
 	// JSON format outputs the AI response wrapped in a JSON object.
 	JSON OutputFormat = "json"
+
+	// StreamJSON format outputs one JSON event per line while the agent runs.
+	StreamJSON OutputFormat = "stream-json"
 )
 
 // String returns the string representation of the OutputFormat
@@ -26,6 +29,7 @@ func (f OutputFormat) String() string {
 var SupportedFormats = []string{
 	string(Text),
 	string(JSON),
+	string(StreamJSON),
 }
 
 // Parse converts a string to an OutputFormat
@@ -37,6 +41,8 @@ func Parse(s string) (OutputFormat, error) {
 		return Text, nil
 	case string(JSON):
 		return JSON, nil
+	case string(StreamJSON):
+		return StreamJSON, nil
 	default:
 		return "", fmt.Errorf("invalid format: %s", s)
 	}
@@ -52,8 +58,9 @@ func IsValid(s string) bool {
 func GetHelpText() string {
 	return fmt.Sprintf(`Supported output formats:
 - %s: Plain text output (default)
-- %s: Output wrapped in a JSON object`,
-		Text, JSON)
+- %s: Output wrapped in a JSON object
+- %s: One JSON event per line (deltas, tool calls, tool results, permissions, usage and the final result)`,
+		Text, JSON, StreamJSON)
 }
 
 // FormatOutput formats the AI response according to the specified format
@@ -18,13 +18,13 @@ const (
 )
 
 type File struct {
-	ID        string
-	SessionID string
-	Path      string
-	Content   string
-	Version   string
-	CreatedAt int64
-	UpdatedAt int64
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Version   string `json:"version"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
 }
 
 type Service interface {
@@ -35,6 +35,7 @@ type Service interface {
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
 	ListBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
+	Import(ctx context.Context, sessionID string, file File) (File, error)
 	Update(ctx context.Context, file File) (File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
@@ -198,6 +199,26 @@ func (s *service) ListLatestSessionFiles(ctx context.Context, sessionID string)
 	return files, nil
 }
 
+// Import copies a file version into the session under a new ID, keeping its
+// version label and timestamps.
+func (s *service) Import(ctx context.Context, sessionID string, file File) (File, error) {
+	dbFile, err := s.q.ImportFile(ctx, db.ImportFileParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Path:      file.Path,
+		Content:   file.Content,
+		Version:   file.Version,
+		CreatedAt: file.CreatedAt,
+		UpdatedAt: file.UpdatedAt,
+	})
+	if err != nil {
+		return File{}, err
+	}
+	imported := s.fromDBItem(dbFile)
+	s.Publish(pubsub.CreatedEvent, imported)
+	return imported, nil
+}
+
 func (s *service) Update(ctx context.Context, file File) (File, error) {
 	dbFile, err := s.q.UpdateFile(ctx, db.UpdateFileParams{
 		ID:      file.ID,
@@ -0,0 +1,139 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is what rewinding does to a single file.
+type Change struct {
+	Path string
+	// Current is the content on disk now, empty when the file is missing.
+	Current string
+	Exists  bool
+	// Target is the content the file is restored to.
+	Target string
+	// Delete is set when the file did not exist at that point.
+	Delete bool
+}
+
+// PlanRewind returns the changes that bring the files touched in the session
+// back to their state before the given unix time. When path is not empty only
+// that file is considered. Files that are already in that state are left out.
+//
+// A file whose content was empty at that point is treated as one that did not
+// exist, since the history does not record the difference.
+func PlanRewind(ctx context.Context, s Service, sessionID string, before int64, path string) ([]Change, error) {
+	files, err := s.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file history: %w", err)
+	}
+
+	var paths []string
+	versions := make(map[string][]File)
+	for _, file := range files {
+		if path != "" && file.Path != path {
+			continue
+		}
+		if _, ok := versions[file.Path]; !ok {
+			paths = append(paths, file.Path)
+		}
+		versions[file.Path] = append(versions[file.Path], file)
+	}
+
+	var changes []Change
+	for _, p := range paths {
+		target, ok := rewindTarget(versions[p], before)
+		if !ok {
+			continue
+		}
+		change := Change{Path: p, Target: target, Delete: target == ""}
+		content, err := os.ReadFile(p)
+		switch {
+		case err == nil:
+			change.Current = string(content)
+			change.Exists = true
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if change.Delete && !change.Exists || !change.Delete && change.Exists && change.Current == change.Target {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// rewindTarget returns the content of the file before the given time. It
+// reports false when the file was not touched after that time.
+func rewindTarget(versions []File, before int64) (string, bool) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].CreatedAt != versions[j].CreatedAt {
+			return versions[i].CreatedAt < versions[j].CreatedAt
+		}
+		return versionNumber(versions[i].Version) < versionNumber(versions[j].Version)
+	})
+	last := -1
+	for i, v := range versions {
+		if v.CreatedAt < before {
+			last = i
+		}
+	}
+	switch {
+	case last == len(versions)-1:
+		return "", false
+	case last >= 0:
+		return versions[last].Content, true
+	case versions[0].Version == InitialVersion:
+		// The initial version holds the content from before the first change.
+		return versions[0].Content, true
+	default:
+		// Without an initial version the file was created in this session.
+		return "", true
+	}
+}
+
+func versionNumber(version string) int {
+	if version == InitialVersion {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ApplyRewind writes the changes to disk and records the restored content as
+// a new version, so the history matches what is on disk.
+func ApplyRewind(ctx context.Context, s Service, sessionID string, changes []Change) error {
+	for _, change := range changes {
+		if change.Delete {
+			if err := os.Remove(change.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to remove %s: %w", change.Path, err)
+			}
+		} else {
+			mode := fs.FileMode(0o644)
+			if info, err := os.Stat(change.Path); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.MkdirAll(filepath.Dir(change.Path), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+			}
+			if err := os.WriteFile(change.Path, []byte(change.Target), mode); err != nil {
+				return fmt.Errorf("failed to write %s: %w", change.Path, err)
+			}
+		}
+		if _, err := s.CreateVersion(ctx, sessionID, change.Path, change.Target); err != nil {
+			return fmt.Errorf("failed to record %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package history
+
+import "testing"
+
+func TestRewindTarget(t *testing.T) {
+	edited := []File{
+		{Version: "v2", Content: "c", CreatedAt: 30},
+		{Version: InitialVersion, Content: "a", CreatedAt: 10},
+		{Version: "v1", Content: "b", CreatedAt: 10},
+	}
+	created := []File{
+		{Version: InitialVersion, Content: "", CreatedAt: 20},
+		{Version: "v1", Content: "new", CreatedAt: 20},
+	}
+	added := []File{
+		{Version: "v3", Content: "added", CreatedAt: 20},
+	}
+
+	tests := []struct {
+		name     string
+		versions []File
+		before   int64
+		want     string
+		changed  bool
+	}{
+		{name: "before any change", versions: edited, before: 5, want: "a", changed: true},
+		{name: "between changes", versions: edited, before: 20, want: "b", changed: true},
+		{name: "after the last change", versions: edited, before: 40, changed: false},
+		{name: "created by the agent", versions: created, before: 15, want: "", changed: true},
+		{name: "added without an initial version", versions: added, before: 15, want: "", changed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := append([]File(nil), tt.versions...)
+			got, changed := rewindTarget(versions, tt.before)
+			if changed != tt.changed || got != tt.want {
+				t.Errorf("rewindTarget() = %q, %v, want %q, %v", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
@@ -25,7 +25,7 @@ import (
 const (
 	PREFIX_EN = "en" // intent to write in English
 	PREFIX_TL = "tl" // translate to English
-	PREFIX_TK = "tk" // /think for qwen3
+	PREFIX_TK = "tk" // think, for models that can switch it per request
 )
 
 // Common errors
@@ -40,6 +40,10 @@ const (
 	AgentEventTypeError     AgentEventType = "error"
 	AgentEventTypeResponse  AgentEventType = "response"
 	AgentEventTypeSummarize AgentEventType = "summarize"
+
+	// AgentEventTypeStepApproval is published in leash mode when the model
+	// proposes tool calls. The agent waits for RespondStep before running them.
+	AgentEventTypeStepApproval AgentEventType = "step_approval"
 )
 
 type AgentEvent struct {
@@ -51,6 +55,9 @@ type AgentEvent struct {
 	SessionID string
 	Progress  string
 	Done      bool
+
+	// When waiting for step approval
+	ToolCalls []message.ToolCall
 }
 
 type Service interface {
@@ -62,6 +69,9 @@ type Service interface {
 	IsBusy() bool
 	Update(agentName config.AgentName, modelID models.ModelID) (models.Model, error)
 	Summarize(ctx context.Context, sessionID string) error
+	SetLeash(sessionID string, enabled bool)
+	IsLeashed(sessionID string) bool
+	RespondStep(sessionID string, decision StepDecision)
 }
 
 type agent struct {
@@ -80,6 +90,9 @@ type agent struct {
 	agentName          config.AgentName
 
 	activeRequests sync.Map
+
+	leashedSessions sync.Map
+	pendingSteps    sync.Map
 }
 
 func NewAgent(
@@ -321,6 +334,9 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	if err != nil {
 		return a.err(fmt.Errorf("failed to create user message: %w", err))
 	}
+	if cp := a.startCheckpoint(ctx); cp != nil {
+		defer cp.finish(sessionID, userMsg)
+	}
 	// Append the new user message to the conversation history.
 	msgHistory := append(msgs, userMsg)
 
@@ -382,7 +398,11 @@ func (a *agent) completeContent(ctx context.Context, content string) (lastConten
 		// 2025.06.16 Kawata: LOCALの時はデフォルトで翻訳（/en で翻訳なし）、それ以外はデフォルトで翻訳なし（/xl で翻訳）
 		body = strings.TrimSpace(body)
 		runTranslate := false
-		isLocal := a.Model().Provider == models.ProviderLocal
+		isLocal := false
+		switch a.Model().Provider {
+		case models.ProviderLocal, models.ProviderOllama, models.ProviderLlamaCpp:
+			isLocal = true
+		}
 		hasEN := slices.Contains(detectedPrefixes, PREFIX_EN)
 		hasXL := slices.Contains(detectedPrefixes, PREFIX_TL)
 		if (isLocal && !hasEN) || (!isLocal && hasXL) {
@@ -405,9 +425,7 @@ func (a *agent) completeContent(ctx context.Context, content string) (lastConten
 			logging.InfoPersist(fmt.Sprintf("Translated: '%s'", body))
 		}
 		// 2025.06.15 Kawata added default-no-think for qwen3
-		model := a.Model()
-		lowerModelName := strings.ToLower(model.Name)
-		if strings.Contains(lowerModelName, "qwen3") {
+		if thinkSwitchInText(a.Model()) {
 			if slices.Contains(detectedPrefixes, PREFIX_TK) { // if has /tk
 				body = fmt.Sprintf("/think %s", body)
 			} else {
@@ -421,6 +439,13 @@ func (a *agent) completeContent(ctx context.Context, content string) (lastConten
 	return
 }
 
+// thinkSwitchInText reports whether thinking is switched by writing /think or
+// /no_think into the prompt, as qwen3 models served through the OpenAI
+// compatible API need. Ollama switches it with a request option instead.
+func thinkSwitchInText(model models.Model) bool {
+	return model.Provider != models.ProviderOllama && strings.Contains(strings.ToLower(model.Name), "qwen3")
+}
+
 func (a *agent) createUserMessage(ctx context.Context, sessionID, content string, attachmentParts []message.ContentPart) (message.Message, []string, []string, error) {
 	// 2025.06.15 Kawata added completion logic for content
 	content, prefixes, detectedPrefixies := a.completeContent(ctx, content)
@@ -435,6 +460,8 @@ func (a *agent) createUserMessage(ctx context.Context, sessionID, content string
 }
 
 func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msgHistory []message.Message, prefixes []string, detectedPrefixes []string) (message.Message, *message.Message, error) {
+	// Models with a thinking switch only think when asked with /tk.
+	ctx = provider.WithThink(ctx, slices.Contains(detectedPrefixes, PREFIX_TK))
 	eventChan := a.provider.StreamResponse(ctx, msgHistory, a.tools)
 
 	assistantMsg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
@@ -464,6 +491,36 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 
 	toolResults := make([]message.ToolResult, len(assistantMsg.ToolCalls()))
 	toolCalls := assistantMsg.ToolCalls()
+	if len(toolCalls) > 0 && a.IsLeashed(sessionID) {
+		decision, stepErr := a.awaitStep(ctx, sessionID, assistantMsg)
+		switch {
+		case stepErr != nil:
+			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled)
+			for i, toolCall := range toolCalls {
+				toolResults[i] = message.ToolResult{
+					ToolCallID: toolCall.ID,
+					Content:    "Tool execution canceled by user",
+					IsError:    true,
+				}
+			}
+			goto out
+		case decision.Action == StepReject:
+			toolResults = rejectedToolResults(toolCalls, decision.Feedback)
+			a.finishMessage(ctx, &assistantMsg, message.FinishReasonRejected)
+			goto out
+		case decision.Action == StepEdit:
+			edited, editErr := applyStepEdit(toolCalls, decision.ToolCalls)
+			if editErr != nil {
+				a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonError)
+				return assistantMsg, nil, editErr
+			}
+			toolCalls = edited
+			assistantMsg.SetToolCalls(toolCalls)
+			if err := a.messages.Update(ctx, assistantMsg); err != nil {
+				return assistantMsg, nil, fmt.Errorf("failed to update message: %w", err)
+			}
+		}
+	}
 	for i, toolCall := range toolCalls {
 		select {
 		case <-ctx.Done():
@@ -531,9 +588,7 @@ out:
 		return assistantMsg, nil, nil
 	}
 	// 2025.06.15 /think /no_think handling also for tool-call-results
-	model := a.Model()
-	lowerModelName := strings.ToLower(model.Name)
-	isQwen3 := strings.Contains(lowerModelName, "qwen3")
+	isQwen3 := thinkSwitchInText(a.Model())
 	isQwen3Think := false
 	if isQwen3 {
 		isQwen3Think = slices.Contains(detectedPrefixes, PREFIX_TK)
@@ -596,6 +651,9 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 	case provider.EventToolUseStop:
 		assistantMsg.FinishToolCall(event.ToolCall.ID)
 		return a.messages.Update(ctx, *assistantMsg)
+	case provider.EventFailover:
+		assistantMsg.Model = event.Model
+		return a.messages.Update(ctx, *assistantMsg)
 	case provider.EventError:
 		if errors.Is(event.Error, context.Canceled) {
 			logging.InfoPersist(fmt.Sprintf("Event processing canceled for session: %s", sessionID))
@@ -609,7 +667,12 @@ func (a *agent) processEvent(ctx context.Context, sessionID string, assistantMsg
 		if err := a.messages.Update(ctx, *assistantMsg); err != nil {
 			return fmt.Errorf("failed to update message: %w", err)
 		}
-		return a.TrackUsage(ctx, sessionID, a.provider.Model(), event.Response.Usage)
+		// The message model follows failovers, unlike the shared provider.
+		model, ok := models.SupportedModels[assistantMsg.Model]
+		if !ok {
+			model = a.provider.Model()
+		}
+		return a.TrackUsage(ctx, sessionID, model, event.Response.Usage)
 	}
 
 	return nil
@@ -832,9 +895,33 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 	if !ok {
 		return nil, fmt.Errorf("agent %s not found", agentName)
 	}
-	model, ok := models.SupportedModels[agentConfig.Model]
+	primary, err := createModelProvider(agentName, agentConfig, agentConfig.Model, agentConfig.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	providers := []provider.Provider{primary}
+	for _, modelID := range agentConfig.Fallbacks {
+		// The configured max tokens are meant for the primary model, so a
+		// fallback only uses them when they fit.
+		maxTokens := agentConfig.MaxTokens
+		if model, ok := models.SupportedModels[modelID]; ok && model.DefaultMaxTokens > 0 && maxTokens > model.DefaultMaxTokens {
+			maxTokens = model.DefaultMaxTokens
+		}
+		fallback, err := createModelProvider(agentName, agentConfig, modelID, maxTokens)
+		if err != nil {
+			logging.Warn("Skipping fallback model", "agent", agentName, "model", modelID, "error", err)
+			continue
+		}
+		providers = append(providers, fallback)
+	}
+	return provider.NewFallbackProvider(providers...), nil
+}
+
+func createModelProvider(agentName config.AgentName, agentConfig config.Agent, modelID models.ModelID, configuredMaxTokens int64) (provider.Provider, error) {
+	cfg := config.Get()
+	model, ok := models.SupportedModels[modelID]
 	if !ok {
-		return nil, fmt.Errorf("model %s not supported", agentConfig.Model)
+		return nil, fmt.Errorf("model %s not supported", modelID)
 	}
 
 	providerCfg, ok := cfg.Providers[model.Provider]
@@ -845,8 +932,8 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 		return nil, fmt.Errorf("provider %s is not enabled", model.Provider)
 	}
 	maxTokens := model.DefaultMaxTokens
-	if agentConfig.MaxTokens > 0 {
-		maxTokens = agentConfig.MaxTokens
+	if configuredMaxTokens > 0 {
+		maxTokens = configuredMaxTokens
 	}
 
 	opts := []provider.ProviderClientOption{
@@ -871,6 +958,39 @@ func createAgentProvider(agentName config.AgentName) (provider.Provider, error)
 				provider.WithAnthropicShouldThinkFn(provider.DefaultShouldThinkFn),
 			),
 		)
+	} else if model.Provider == models.ProviderOllama {
+		opts = append(
+			opts,
+			provider.WithEndpoint(providerCfg.Endpoint),
+			provider.WithOllamaOptions(
+				provider.WithOllamaKeepAlive(providerCfg.KeepAlive),
+				provider.WithOllamaNumCtx(providerCfg.NumCtx),
+			),
+		)
+	} else if models.IsCustomProvider(model.Provider) {
+		openaiOptions := []provider.OpenAIOption{
+			provider.WithOpenAIExtraHeaders(providerCfg.Headers),
+		}
+		if model.CanReason && agentConfig.ReasoningEffort != "" {
+			openaiOptions = append(openaiOptions, provider.WithReasoningEffort(agentConfig.ReasoningEffort))
+		}
+		opts = append(
+			opts,
+			provider.WithEndpoint(providerCfg.Endpoint),
+			provider.WithOpenAIOptions(openaiOptions...),
+		)
+	} else if model.Provider == models.ProviderLlamaCpp {
+		opts = append(opts, provider.WithEndpoint(providerCfg.Endpoint))
+	} else if model.Provider == models.ProviderMock {
+		// Each agent replays its own script so that concurrent title generation
+		// does not consume the coder's responses.
+		opts = append(
+			opts,
+			provider.WithEndpoint(providerCfg.Endpoint),
+			provider.WithMockOptions(
+				provider.WithMockScriptName(string(agentName)),
+			),
+		)
 	}
 	agentProvider, err := provider.NewProvider(
 		model.Provider,
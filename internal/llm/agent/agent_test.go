@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/db"
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/provider"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/pubsub"
+	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loadConfigOnce sync.Once
+
+type echoTool struct {
+	calls []string
+}
+
+func (e *echoTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{
+		Name:        "echo",
+		Description: "Echoes its input",
+		Parameters:  map[string]any{},
+	}
+}
+
+func (e *echoTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	e.calls = append(e.calls, call.Input)
+	return tools.NewTextResponse("echo: " + call.Input), nil
+}
+
+type testEnv struct {
+	sessions session.Service
+	messages message.Service
+	tasks    task.Service
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	tmpDir := t.TempDir()
+	loadConfigOnce.Do(func() {
+		_, err := config.Load(tmpDir, false)
+		require.NoError(t, err)
+	})
+	config.Get().Data.Directory = tmpDir
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	return testEnv{
+		sessions: session.NewService(q),
+		messages: message.NewService(q),
+		tasks:    task.NewService(q),
+	}
+}
+
+func (env testEnv) newMockAgent(t *testing.T, agentTools []tools.BaseTool, opts ...provider.MockOption) *agent {
+	t.Helper()
+	p, err := provider.NewProvider(models.ProviderMock,
+		provider.WithModel(models.SupportedModels[models.MockScripted]),
+		provider.WithMockOptions(opts...),
+	)
+	require.NoError(t, err)
+	return &agent{
+		Broker:    pubsub.NewBroker[AgentEvent](),
+		provider:  p,
+		sessions:  env.sessions,
+		messages:  env.messages,
+		tools:     agentTools,
+		agentName: config.AgentTask,
+	}
+}
+
+func TestProcessGeneration_ToolLoop(t *testing.T) {
+	env := newTestEnv(t)
+	echo := &echoTool{}
+
+	var requests [][]message.Message
+	a := env.newMockAgent(t, []tools.BaseTool{echo},
+		provider.WithMockResponses(
+			provider.MockResponse{
+				Content:   []string{"Let me check."},
+				ToolCalls: []provider.MockToolCall{{ID: "call-1", Name: "echo", Input: []byte(`{"v":1}`)}},
+				Usage:     provider.MockUsage{InputTokens: 100, OutputTokens: 10},
+			},
+			provider.MockResponse{
+				Content: []string{"Done", "."},
+				Usage:   provider.MockUsage{InputTokens: 150, OutputTokens: 5},
+			},
+		),
+		provider.WithMockRecorder(func(msgs []message.Message, _ []tools.BaseTool) {
+			requests = append(requests, msgs)
+		}),
+	)
+
+	ctx := context.Background()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+
+	result := a.processGeneration(ctx, sess.ID, "run echo", nil)
+	require.NoError(t, result.Error)
+	assert.Equal(t, AgentEventTypeResponse, result.Type)
+	assert.Equal(t, "Done.", result.Message.Content().String())
+	assert.Equal(t, []string{`{"v":1}`}, echo.calls)
+
+	msgs, err := env.messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	var roles []message.MessageRole
+	for _, m := range msgs {
+		roles = append(roles, m.Role)
+	}
+	assert.Equal(t, []message.MessageRole{message.User, message.Assistant, message.Tool, message.Assistant}, roles)
+	require.Len(t, msgs[2].ToolResults(), 1)
+	assert.Equal(t, `echo: {"v":1}`, msgs[2].ToolResults()[0].Content)
+
+	// The second request must carry the tool result back to the model.
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[1], 3)
+
+	updated, err := env.sessions.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), updated.PromptTokens)
+}
+
+func TestProcessGeneration_ProviderError(t *testing.T) {
+	env := newTestEnv(t)
+	a := env.newMockAgent(t, nil, provider.WithMockResponses(
+		provider.MockResponse{Content: []string{"partial"}, Error: "upstream unavailable"},
+	))
+
+	ctx := context.Background()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+
+	result := a.processGeneration(ctx, sess.ID, "hello", nil)
+	require.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "upstream unavailable")
+}
+
+func TestProcessGeneration_TodoKeepsVetoedTasks(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+
+	first, err := env.tasks.Create(ctx, sess.ID, 0, "Write migration", task.StatusPending)
+	require.NoError(t, err)
+	second, err := env.tasks.Create(ctx, sess.ID, 1, "Drop old table", task.StatusPending)
+	require.NoError(t, err)
+	_, err = env.tasks.ToggleVeto(ctx, second.ID)
+	require.NoError(t, err)
+
+	input := `{"todos":[{"id":"` + first.ID + `","content":"Write migration","status":"completed"},{"id":"` + second.ID + `","content":"Drop old table","status":"in_progress"},{"content":"Update docs","status":"pending"}]}`
+	a := env.newMockAgent(t, []tools.BaseTool{tools.NewTodoTool(env.tasks)},
+		provider.WithMockResponses(
+			provider.MockResponse{ToolCalls: []provider.MockToolCall{{Name: tools.TodoToolName, Input: []byte(input)}}},
+			provider.MockResponse{Content: []string{"ok"}},
+		),
+	)
+
+	result := a.processGeneration(ctx, sess.ID, "plan it", nil)
+	require.NoError(t, result.Error)
+
+	tasks, err := env.tasks.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	assert.Equal(t, task.StatusCompleted, tasks[0].Status)
+	assert.Equal(t, task.StatusVetoed, tasks[1].Status)
+	assert.Equal(t, "Update docs", tasks[2].Content)
+	assert.Equal(t, task.StatusPending, tasks[2].Status)
+
+	msgs, err := env.messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs[2].ToolResults(), 1)
+	assert.Contains(t, msgs[2].ToolResults()[0].Content, "Do NOT execute them")
+}
+
+func TestProcessGeneration_LeashEditThenReject(t *testing.T) {
+	env := newTestEnv(t)
+	echo := &echoTool{}
+	a := env.newMockAgent(t, []tools.BaseTool{echo},
+		provider.WithMockResponses(
+			provider.MockResponse{ToolCalls: []provider.MockToolCall{{ID: "call-1", Name: "echo", Input: []byte(`{"v":1}`)}}},
+			provider.MockResponse{ToolCalls: []provider.MockToolCall{{ID: "call-2", Name: "echo", Input: []byte(`{"v":3}`)}}},
+		),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+	a.SetLeash(sess.ID, true)
+
+	events := a.Subscribe(ctx)
+	go func() {
+		steps := 0
+		for event := range events {
+			if event.Payload.Type != AgentEventTypeStepApproval {
+				continue
+			}
+			steps++
+			if steps == 1 {
+				edited := event.Payload.ToolCalls[0]
+				edited.Input = `{"v":2}`
+				a.RespondStep(sess.ID, StepDecision{Action: StepEdit, ToolCalls: []message.ToolCall{edited}})
+				continue
+			}
+			a.RespondStep(sess.ID, StepDecision{Action: StepReject, Feedback: "stop here"})
+		}
+	}()
+
+	result := a.processGeneration(ctx, sess.ID, "run echo", nil)
+	require.NoError(t, result.Error)
+	assert.Equal(t, message.FinishReasonRejected, result.Message.FinishReason())
+	assert.Equal(t, []string{`{"v":2}`}, echo.calls)
+
+	msgs, err := env.messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 5)
+	assert.Equal(t, `{"v":2}`, msgs[1].ToolCalls()[0].Input)
+	require.Len(t, msgs[4].ToolResults(), 1)
+	assert.True(t, msgs[4].ToolResults()[0].IsError)
+	assert.Contains(t, msgs[4].ToolResults()[0].Content, "stop here")
+}
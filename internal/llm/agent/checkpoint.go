@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/checkpoint"
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+)
+
+const checkpointSubjectLength = 72
+
+// turnCheckpoint remembers the working tree at the start of an agent turn.
+type turnCheckpoint struct {
+	repo   *checkpoint.Repo
+	before string
+}
+
+// startCheckpoint snapshots the working tree when git checkpoints are enabled.
+// It returns nil when they are not, or when the snapshot fails.
+func (a *agent) startCheckpoint(ctx context.Context) *turnCheckpoint {
+	cfg := config.Get()
+	if a.agentName != config.AgentCoder || !cfg.Checkpoints {
+		return nil
+	}
+	repo, err := checkpoint.Open(ctx, config.WorkingDirectory(), cfg.Data.Directory)
+	if err != nil {
+		logging.Warn("Checkpoints are disabled", "error", err)
+		return nil
+	}
+	before, err := repo.WriteTree(ctx)
+	if err != nil {
+		logging.Warn("Failed to snapshot the working tree", "error", err)
+		return nil
+	}
+	return &turnCheckpoint{repo: repo, before: before}
+}
+
+// finish records a checkpoint for the turn if it changed any file. It runs
+// even when the turn was cancelled, since tools may already have written.
+func (c *turnCheckpoint) finish(sessionID string, userMsg message.Message) {
+	subject := strings.Join(strings.Fields(userMsg.Content().Text), " ")
+	if len([]rune(subject)) > checkpointSubjectLength {
+		subject = string([]rune(subject)[:checkpointSubjectLength-1]) + "…"
+	}
+	cp, ok, err := c.repo.Record(context.Background(), sessionID, c.before, userMsg.ID, subject)
+	if err != nil {
+		logging.Warn("Failed to record checkpoint", "error", err)
+		return
+	}
+	if ok {
+		logging.Info("Checkpoint recorded", "session_id", sessionID, "commit", cp.Commit)
+	}
+}
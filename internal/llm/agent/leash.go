@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/pubsub"
+)
+
+// StepAction is the user's answer to the tool calls proposed in leash mode.
+type StepAction string
+
+const (
+	StepApprove StepAction = "approve"
+	StepEdit    StepAction = "edit"
+	StepReject  StepAction = "reject"
+)
+
+var ErrStepEditInvalid = errors.New("edited tool calls do not match the proposed calls")
+
+// StepDecision answers an AgentEventTypeStepApproval event.
+type StepDecision struct {
+	Action StepAction
+	// ToolCalls carries the edited calls for StepEdit. Calls are matched to the
+	// proposed ones by ID and only their input is replaced.
+	ToolCalls []message.ToolCall
+	// Feedback is passed back to the model when the step is rejected.
+	Feedback string
+}
+
+func (a *agent) SetLeash(sessionID string, enabled bool) {
+	if enabled {
+		a.leashedSessions.Store(sessionID, true)
+		return
+	}
+	a.leashedSessions.Delete(sessionID)
+}
+
+func (a *agent) IsLeashed(sessionID string) bool {
+	_, ok := a.leashedSessions.Load(sessionID)
+	return ok
+}
+
+func (a *agent) RespondStep(sessionID string, decision StepDecision) {
+	respCh, ok := a.pendingSteps.Load(sessionID)
+	if ok {
+		respCh.(chan StepDecision) <- decision
+	}
+}
+
+// awaitStep publishes the proposed tool calls and blocks until the user
+// answers or the request is cancelled.
+func (a *agent) awaitStep(ctx context.Context, sessionID string, assistantMsg message.Message) (StepDecision, error) {
+	respCh := make(chan StepDecision, 1)
+	a.pendingSteps.Store(sessionID, respCh)
+	defer a.pendingSteps.Delete(sessionID)
+
+	a.Publish(pubsub.CreatedEvent, AgentEvent{
+		Type:      AgentEventTypeStepApproval,
+		SessionID: sessionID,
+		Message:   assistantMsg,
+		ToolCalls: assistantMsg.ToolCalls(),
+	})
+
+	select {
+	case <-ctx.Done():
+		return StepDecision{}, ctx.Err()
+	case decision := <-respCh:
+		logging.Debug("Step decision received", "sessionID", sessionID, "action", decision.Action)
+		return decision, nil
+	}
+}
+
+// applyStepEdit replaces the input of the proposed tool calls with the edited ones.
+func applyStepEdit(proposed []message.ToolCall, edited []message.ToolCall) ([]message.ToolCall, error) {
+	inputs := make(map[string]string, len(edited))
+	for _, call := range edited {
+		inputs[call.ID] = call.Input
+	}
+	result := make([]message.ToolCall, len(proposed))
+	for i, call := range proposed {
+		if input, ok := inputs[call.ID]; ok {
+			call.Input = input
+			delete(inputs, call.ID)
+		}
+		result[i] = call
+	}
+	if len(inputs) > 0 {
+		return nil, ErrStepEditInvalid
+	}
+	return result, nil
+}
+
+func rejectedToolResults(toolCalls []message.ToolCall, feedback string) []message.ToolResult {
+	content := "The user rejected this tool call. Do not retry it; wait for new instructions."
+	if feedback != "" {
+		content = fmt.Sprintf("The user rejected this tool call with the following feedback: %s", feedback)
+	}
+	results := make([]message.ToolResult, len(toolCalls))
+	for i, call := range toolCalls {
+		results[i] = message.ToolResult{
+			ToolCallID: call.ID,
+			Content:    content,
+			IsError:    true,
+		}
+	}
+	return results
+}
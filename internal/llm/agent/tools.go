@@ -9,6 +9,7 @@ import (
 	"github.com/cap-ai/cap/internal/message"
 	"github.com/cap-ai/cap/internal/permission"
 	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
 )
 
 func CoderAgentTools(
@@ -16,6 +17,7 @@ func CoderAgentTools(
 	sessions session.Service,
 	messages message.Service,
 	history history.Service,
+	tasks task.Service,
 	lspClients map[string]*lsp.Client,
 ) []tools.BaseTool {
 	ctx := context.Background()
@@ -35,6 +37,7 @@ func CoderAgentTools(
 			tools.NewViewTool(lspClients),
 			tools.NewPatchTool(lspClients, permissions, history),
 			tools.NewWriteTool(lspClients, permissions, history),
+			tools.NewTodoTool(tasks),
 			NewAgentTool(sessions, messages, lspClients),
 		}, otherTools...,
 	)
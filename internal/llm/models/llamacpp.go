@@ -0,0 +1,93 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/logging"
+)
+
+const (
+	ProviderLlamaCpp ModelProvider = "llamacpp"
+
+	defaultLlamaCppEndpoint = "http://localhost:8080"
+)
+
+// LlamaCppEndpoint returns the base URL of the llama.cpp server.
+func LlamaCppEndpoint(endpoint string) string {
+	endpoint = cmp.Or(endpoint, defaultLlamaCppEndpoint)
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	// Endpoints written for the OpenAI compatible API also work here.
+	endpoint = strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/v1")
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func llamacppGet(ctx context.Context, url, apiKey string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	res, err := localHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// InitLlamaCpp registers the model served by the llama.cpp server, with the
+// context length of a slot as reported by /props.
+func InitLlamaCpp(endpoint, apiKey string) {
+	if err := initLlamaCpp(context.Background(), LlamaCppEndpoint(endpoint), apiKey); err != nil {
+		logging.Debug("Failed to load llama.cpp model", "error", err, "endpoint", endpoint)
+	}
+}
+
+func initLlamaCpp(ctx context.Context, endpoint, apiKey string) error {
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := llamacppGet(ctx, endpoint+"/v1/models", apiKey, &list); err != nil {
+		return err
+	}
+	if len(list.Data) == 0 {
+		return fmt.Errorf("no model loaded at %s", endpoint)
+	}
+	var props struct {
+		DefaultGenerationSettings struct {
+			NCtx int64 `json:"n_ctx"`
+		} `json:"default_generation_settings"`
+	}
+	if err := llamacppGet(ctx, endpoint+"/props", apiKey, &props); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(list.Data[0].ID), ".gguf")
+	contextWindow := cmp.Or(props.DefaultGenerationSettings.NCtx, 4096)
+	model := Model{
+		ID:               ModelID("llamacpp." + name),
+		Name:             friendlyModelName(name),
+		Provider:         ProviderLlamaCpp,
+		APIModel:         list.Data[0].ID,
+		ContextWindow:    contextWindow,
+		DefaultMaxTokens: contextWindow,
+	}
+	SupportedModels[model.ID] = model
+	ProviderPopularity[ProviderLlamaCpp] = 0
+	return nil
+}
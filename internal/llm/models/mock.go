@@ -0,0 +1,17 @@
+package models
+
+const (
+	// MockScripted replays responses from a fixture file instead of calling an API.
+	MockScripted ModelID = "__mock.scripted"
+)
+
+var MockModels = map[ModelID]Model{
+	MockScripted: {
+		ID:               MockScripted,
+		Name:             "Mock: Scripted",
+		Provider:         ProviderMock,
+		APIModel:         "scripted",
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 4096,
+	},
+}
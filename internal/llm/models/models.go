@@ -2,6 +2,7 @@ package models
 
 import (
 	"maps"
+	"slices"
 )
 
 type (
@@ -22,6 +23,9 @@ type Model struct {
 	DefaultMaxTokens    int64         `json:"default_max_tokens"`
 	CanReason           bool          `json:"can_reason"`
 	SupportsAttachments bool          `json:"supports_attachments"`
+	// NoTools is set for models that cannot call tools. Requests to them are
+	// sent without tool definitions.
+	NoTools bool `json:"no_tools,omitempty"`
 }
 
 // Model IDs
@@ -48,6 +52,41 @@ var ProviderPopularity = map[ModelProvider]int{
 	ProviderVertexAI:   8,
 }
 
+var builtinProviders = []ModelProvider{
+	ProviderAnthropic,
+	ProviderOpenAI,
+	ProviderGemini,
+	ProviderGROQ,
+	ProviderOpenRouter,
+	ProviderBedrock,
+	ProviderAzure,
+	ProviderVertexAI,
+	ProviderXAI,
+	ProviderLocal,
+	ProviderOllama,
+	ProviderLlamaCpp,
+	ProviderMock,
+}
+
+// customProviders are the OpenAI compatible providers declared in the config.
+var customProviders = map[ModelProvider]bool{}
+
+// IsBuiltinProvider reports whether the provider has its own client.
+func IsBuiltinProvider(provider ModelProvider) bool {
+	return slices.Contains(builtinProviders, provider)
+}
+
+// RegisterCustomProvider makes an OpenAI compatible provider declared in the
+// config available to models.
+func RegisterCustomProvider(provider ModelProvider) {
+	customProviders[provider] = true
+}
+
+// IsCustomProvider reports whether the provider was declared in the config.
+func IsCustomProvider(provider ModelProvider) bool {
+	return customProviders[provider]
+}
+
 var SupportedModels = map[ModelID]Model{
 	//
 	// // GEMINI
@@ -97,4 +136,5 @@ func init() {
 	maps.Copy(SupportedModels, VertexAIGeminiModels)
 	// 2025.06.14 Kawata added models and translater agent
 	maps.Copy(SupportedModels, OpenRouterModels)
+	maps.Copy(SupportedModels, MockModels)
 }
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cap-ai/cap/internal/logging"
+)
+
+const (
+	ProviderOllama ModelProvider = "ollama"
+
+	defaultOllamaEndpoint = "http://localhost:11434"
+)
+
+// OllamaEndpoint returns the base URL of the Ollama server: the configured
+// endpoint, then OLLAMA_HOST, then the default local server.
+func OllamaEndpoint(endpoint string) string {
+	endpoint = cmp.Or(endpoint, os.Getenv("OLLAMA_HOST"), defaultOllamaEndpoint)
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	// Endpoints written for the OpenAI compatible API also work here.
+	endpoint = strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/v1")
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// OllamaModelInfo is what Ollama reports about an installed model.
+type OllamaModelInfo struct {
+	Name          string
+	Size          int64
+	ContextLength int64
+	Capabilities  []string
+}
+
+// Has reports whether the model has the capability, such as "tools",
+// "thinking" or "vision".
+func (m OllamaModelInfo) Has(capability string) bool {
+	return slices.Contains(m.Capabilities, capability)
+}
+
+type ollamaTags struct {
+	Models []struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	} `json:"models"`
+}
+
+type ollamaShow struct {
+	Capabilities []string       `json:"capabilities"`
+	ModelInfo    map[string]any `json:"model_info"`
+}
+
+var localHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func ollamaPost(ctx context.Context, client *http.Client, url string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&apiErr)
+		return nil, fmt.Errorf("POST %s: %s: %s", url, res.Status, apiErr.Error)
+	}
+	return res, nil
+}
+
+// ListOllamaModels returns the installed models with their context length and
+// capabilities, as reported by /api/tags and /api/show.
+func ListOllamaModels(ctx context.Context, endpoint string) ([]OllamaModelInfo, error) {
+	endpoint = OllamaEndpoint(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := localHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s/api/tags: %s", endpoint, res.Status)
+	}
+	var tags ollamaTags
+	if err := json.NewDecoder(res.Body).Decode(&tags); err != nil {
+		return nil, err
+	}
+
+	infos := make([]OllamaModelInfo, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		info, err := ShowOllamaModel(ctx, endpoint, m.Name)
+		if err != nil {
+			logging.Debug("Failed to show Ollama model", "model", m.Name, "error", err)
+			continue
+		}
+		info.Size = m.Size
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// ShowOllamaModel returns the context length and capabilities of a model.
+func ShowOllamaModel(ctx context.Context, endpoint, name string) (OllamaModelInfo, error) {
+	res, err := ollamaPost(ctx, localHTTPClient, OllamaEndpoint(endpoint)+"/api/show", map[string]any{"model": name})
+	if err != nil {
+		return OllamaModelInfo{}, err
+	}
+	defer res.Body.Close()
+	var show ollamaShow
+	if err := json.NewDecoder(res.Body).Decode(&show); err != nil {
+		return OllamaModelInfo{}, err
+	}
+	info := OllamaModelInfo{Name: name, Capabilities: show.Capabilities}
+	for k, v := range show.ModelInfo {
+		if strings.HasSuffix(k, ".context_length") {
+			if cl, err := anyToInt64(v); err == nil {
+				info.ContextLength = cl
+			}
+		}
+	}
+	return info, nil
+}
+
+// OllamaPullProgress is a status line reported while pulling a model.
+type OllamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// PullOllamaModel downloads a model, calling progress for each status line.
+func PullOllamaModel(ctx context.Context, endpoint, name string, progress func(OllamaPullProgress)) error {
+	res, err := ollamaPost(ctx, &http.Client{}, OllamaEndpoint(endpoint)+"/api/pull", map[string]any{"model": name})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var line struct {
+			OllamaPullProgress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return err
+		}
+		if line.Error != "" {
+			return fmt.Errorf("pull %s: %s", name, line.Error)
+		}
+		if progress != nil {
+			progress(line.OllamaPullProgress)
+		}
+	}
+	return scanner.Err()
+}
+
+// InitOllama registers the models installed on the Ollama server. numCtx is
+// the context length requested for each model, 0 for the server default.
+func InitOllama(endpoint string, numCtx int64) {
+	infos, err := ListOllamaModels(context.Background(), endpoint)
+	if err != nil {
+		logging.Debug("Failed to list Ollama models", "error", err, "endpoint", endpoint)
+		return
+	}
+	if len(infos) == 0 {
+		logging.Debug("No Ollama models found", "endpoint", endpoint)
+		return
+	}
+	for _, info := range infos {
+		model := convertOllamaModel(info, numCtx)
+		SupportedModels[model.ID] = model
+	}
+	ProviderPopularity[ProviderOllama] = 0
+}
+
+func convertOllamaModel(info OllamaModelInfo, numCtx int64) Model {
+	contextWindow := cmp.Or(info.ContextLength, 4096)
+	if numCtx > 0 && numCtx < contextWindow {
+		contextWindow = numCtx
+	}
+	return Model{
+		ID:                  ModelID("ollama." + info.Name),
+		Name:                friendlyModelName(info.Name),
+		Provider:            ProviderOllama,
+		APIModel:            info.Name,
+		ContextWindow:       contextWindow,
+		DefaultMaxTokens:    contextWindow,
+		CanReason:           info.Has("thinking"),
+		SupportsAttachments: info.Has("vision"),
+		// Older servers do not report capabilities at all.
+		NoTools: len(info.Capabilities) > 0 && !info.Has("tools"),
+	}
+}
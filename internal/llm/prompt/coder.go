@@ -16,7 +16,7 @@ import (
 func CoderPrompt(provider models.ModelProvider) string {
 	basePrompt := baseAnthropicCoderPrompt
 	switch provider {
-	case models.ProviderLocal: // 2025.06.15 Kawata added base prompt for local
+	case models.ProviderLocal, models.ProviderOllama, models.ProviderLlamaCpp: // 2025.06.15 Kawata added base prompt for local
 		basePrompt = baseLocalCoderPrompt
 	case models.ProviderOpenAI:
 		basePrompt = baseOpenAICoderPrompt
@@ -109,6 +109,7 @@ You MUST adhere to the following criteria when executing the task:
 - When doing things with paths, always use use the full path, if the working directory is /abc/xyz  and you want to edit the file abc.go in the working dir refer to it as /abc/xyz/abc.go.
 - If you send a path not including the working dir, the working dir will be prepended to it.
 - Remember the user does not see the full output of tools
+- For multi-step tasks (including markdown checklists such as "- [ ] task"), record the plan with the todo tool, keep each step's status up to date, and never execute a step the user vetoed
 - Think entirely in English and only use Japanese when you need to speak to users
 `
 
@@ -157,6 +158,7 @@ You MUST adhere to the following criteria when executing the task:
 - When doing things with paths, always use use the full path, if the working directory is /abc/xyz  and you want to edit the file abc.go in the working dir refer to it as /abc/xyz/abc.go.
 - If you send a path not including the working dir, the working dir will be prepended to it.
 - Remember the user does not see the full output of tools
+- For multi-step tasks (including markdown checklists such as "- [ ] task"), record the plan with the todo tool, keep each step's status up to date, and never execute a step the user vetoed
 - Think entirely in English and only use Japanese when you need to speak to users
 `
 
@@ -205,6 +207,7 @@ You MUST adhere to the following criteria when executing the task:
 - When doing things with paths, always use use the full path, if the working directory is /abc/xyz  and you want to edit the file abc.go in the working dir refer to it as /abc/xyz/abc.go.
 - If you send a path not including the working dir, the working dir will be prepended to it.
 - Remember the user does not see the full output of tools
+- For multi-step tasks (including markdown checklists such as "- [ ] task"), record the plan with the todo tool, keep each step's status up to date, and never execute a step the user vetoed
 - Think entirely in English and only use Japanese when you need to speak to users
 `
 
@@ -296,6 +299,8 @@ The user will primarily request you perform software engineering tasks. This inc
 3. Verify the solution if possible with tests. NEVER assume specific test framework or test script. Check the README or search codebase to determine the testing approach.
 4. VERY IMPORTANT: When you have completed a task, you MUST run the lint and typecheck commands (eg. npm run lint, npm run typecheck, ruff, etc.) if they were provided to you to ensure your code is correct. If you are unable to find the correct command, ask the user for the command to run and if they supply it, proactively suggest writing it to cap.md so that you will know to run it next time.
 
+When a task has several steps (including markdown checklists such as "- [ ] task"), record the plan with the todo tool before starting, mark each step in_progress and completed as you go, and never execute a step the user vetoed.
+
 NEVER commit changes unless the user explicitly asks you to. It is VERY IMPORTANT to only commit when explicitly asked, otherwise the user will feel that you are being too proactive.
 
 # Tool usage policy
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/openai/openai-go"
+	"google.golang.org/genai"
+)
+
+// fallbackCooldown is how long requests stay on a fallback model before the
+// primary model is tried again.
+const fallbackCooldown = 5 * time.Minute
+
+// fallbackProvider sends requests to the first provider of the chain and moves
+// on to the next one when a provider fails in a way that retrying the same
+// provider will not fix.
+type fallbackProvider struct {
+	providers []Provider
+
+	mu       sync.Mutex
+	active   int
+	failedAt time.Time
+}
+
+// NewFallbackProvider chains the providers in order of preference. With a
+// single provider it is returned as is.
+func NewFallbackProvider(providers ...Provider) Provider {
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return &fallbackProvider{providers: providers}
+}
+
+func (p *fallbackProvider) current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active > 0 && time.Since(p.failedAt) > fallbackCooldown {
+		p.active = 0
+	}
+	return p.active
+}
+
+func (p *fallbackProvider) failover(from int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active <= from {
+		p.active = from + 1
+		p.failedAt = time.Now()
+	}
+	logging.WarnPersist(fmt.Sprintf("%s failed, switching to %s: %v", p.providers[from].Model().Name, p.providers[from+1].Model().Name, err))
+}
+
+func (p *fallbackProvider) Model() models.Model {
+	return p.providers[p.current()].Model()
+}
+
+func (p *fallbackProvider) SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	for i := p.current(); ; i++ {
+		provider := p.providers[i]
+		response, err := provider.SendMessages(ctx, adaptMessages(messages, provider.Model(), i > 0), tools)
+		if err == nil || i == len(p.providers)-1 || !ShouldFailover(err) {
+			return response, err
+		}
+		p.failover(i, err)
+	}
+}
+
+func (p *fallbackProvider) StreamResponse(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+	go func() {
+		defer close(eventChan)
+		for i := p.current(); ; i++ {
+			provider := p.providers[i]
+			if i > 0 {
+				eventChan <- ProviderEvent{Type: EventFailover, Model: provider.Model().ID}
+			}
+			started := false
+			var failErr error
+			for event := range provider.StreamResponse(ctx, adaptMessages(messages, provider.Model(), i > 0), tools) {
+				// Nothing has been produced yet, so the next provider can
+				// take over without the agent noticing.
+				if event.Type == EventError && !started && i < len(p.providers)-1 && ShouldFailover(event.Error) {
+					failErr = event.Error
+					continue
+				}
+				switch event.Type {
+				case EventContentDelta, EventThinkingDelta, EventToolUseStart, EventComplete:
+					started = true
+				}
+				eventChan <- event
+			}
+			if failErr == nil {
+				return
+			}
+			p.failover(i, failErr)
+		}
+	}()
+	return eventChan
+}
+
+// ShouldFailover reports whether the error means the provider cannot serve
+// requests for now: retries were exhausted, the key was rejected or is out of
+// quota, the service is down, or it could not be reached at all.
+func ShouldFailover(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRetriesExhausted) {
+		return true
+	}
+
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return failoverStatus(anthropicErr.StatusCode)
+	}
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return failoverStatus(openaiErr.StatusCode)
+	}
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		return failoverStatus(geminiErr.Code)
+	}
+	var ollamaErr *ollamaError
+	if errors.As(err, &ollamaErr) {
+		return failoverStatus(ollamaErr.StatusCode)
+	}
+	var llamacppErr *llamacppError
+	if errors.As(err, &llamacppErr) {
+		return failoverStatus(llamacppErr.StatusCode)
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return contains(msg, "quota", "api key", "unauthorized", "connection refused")
+}
+
+func failoverStatus(status int) bool {
+	switch {
+	case status == 401, status == 402, status == 403, status == 429:
+		return true
+	case status >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+var invalidToolCallID = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// adaptMessages converts a history written with another model for the given
+// one. Attachments are dropped when the model does not accept them and, for
+// fallbacks, tool call IDs are rewritten to a form every provider accepts.
+func adaptMessages(messages []message.Message, model models.Model, fallback bool) []message.Message {
+	if !fallback && model.SupportsAttachments {
+		return messages
+	}
+	adapted := make([]message.Message, 0, len(messages))
+	for _, msg := range messages {
+		parts := make([]message.ContentPart, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case message.BinaryContent:
+				if !model.SupportsAttachments {
+					continue
+				}
+			case message.ToolCall:
+				if fallback {
+					p.ID = adaptToolCallID(p.ID)
+					part = p
+				}
+			case message.ToolResult:
+				if fallback {
+					p.ToolCallID = adaptToolCallID(p.ToolCallID)
+					part = p
+				}
+			}
+			parts = append(parts, part)
+		}
+		msg.Parts = parts
+		adapted = append(adapted, msg)
+	}
+	return adapted
+}
+
+func adaptToolCallID(id string) string {
+	id = invalidToolCallID.ReplaceAllString(id, "_")
+	if len(id) > 64 {
+		id = id[len(id)-64:]
+	}
+	return id
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackProvider_StreamFailsOver(t *testing.T) {
+	t.Parallel()
+
+	var received []message.Message
+	primary := newTestMockProvider(t, WithMockResponses(
+		MockResponse{Error: "quota exceeded"},
+	))
+	fallback := newTestMockProvider(t,
+		WithMockResponses(
+			MockResponse{Content: []string{"from fallback"}},
+			MockResponse{Content: []string{"still fallback"}},
+		),
+		WithMockRecorder(func(messages []message.Message, _ []tools.BaseTool) { received = messages }),
+	)
+	p := NewFallbackProvider(primary, fallback)
+
+	history := []message.Message{
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "toolu:01/abc", Name: "ls"}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "toolu:01/abc"}}},
+	}
+	events := collectEvents(p.StreamResponse(context.Background(), history, nil))
+
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventFailover, events[0].Type)
+	last := events[len(events)-1]
+	require.Equal(t, EventComplete, last.Type)
+	assert.Equal(t, "from fallback", last.Response.Content)
+	for _, e := range events {
+		assert.NotEqual(t, EventError, e.Type)
+	}
+
+	// Tool call IDs are rewritten consistently for the fallback model.
+	require.Len(t, received, 2)
+	call := received[0].ToolCalls()[0]
+	result := received[1].ToolResults()[0]
+	assert.Equal(t, "toolu_01_abc", call.ID)
+	assert.Equal(t, call.ID, result.ToolCallID)
+	assert.Equal(t, "toolu:01/abc", history[0].ToolCalls()[0].ID, "the original history must not change")
+
+	// The next request goes straight to the fallback.
+	resp, err := p.SendMessages(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "still fallback", resp.Content)
+}
+
+func TestFallbackProvider_KeepsOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	primary := newTestMockProvider(t, WithMockResponses(MockResponse{Error: "invalid request"}))
+	fallback := newTestMockProvider(t, WithMockResponses(MockResponse{Content: []string{"unused"}}))
+	p := NewFallbackProvider(primary, fallback)
+
+	_, err := p.SendMessages(context.Background(), nil, nil)
+	assert.EqualError(t, err, "invalid request")
+	assert.Equal(t, models.MockScripted, p.Model().ID)
+}
+
+func TestShouldFailover(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, ShouldFailover(fmt.Errorf("%w for rate limit: %d retries", ErrRetriesExhausted, maxRetries)))
+	assert.True(t, ShouldFailover(errors.New("Quota exceeded for this project")))
+	assert.False(t, ShouldFailover(context.Canceled))
+	assert.False(t, ShouldFailover(fmt.Errorf("stream: %w", context.Canceled)))
+	assert.False(t, ShouldFailover(errors.New("prompt is too long")))
+}
@@ -394,7 +394,7 @@ func (g *geminiClient) stream(ctx context.Context, messages []message.Message, t
 func (g *geminiClient) shouldRetry(attempts int, err error) (bool, int64, error) {
 	// Check if error is a rate limit error
 	if attempts > maxRetries {
-		return false, 0, fmt.Errorf("maximum retry attempts reached for rate limit: %d retries", maxRetries)
+		return false, 0, fmt.Errorf("%w for rate limit: %d retries", ErrRetriesExhausted, maxRetries)
 	}
 
 	// Gemini doesn't have a standard error type we can check against
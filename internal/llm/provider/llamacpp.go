@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/google/uuid"
+)
+
+type llamacppClient struct {
+	providerOptions providerClientOptions
+	baseURL         string
+	client          *http.Client
+}
+
+type LlamaCppClient ProviderClient
+
+func newLlamaCppClient(opts providerClientOptions) LlamaCppClient {
+	return &llamacppClient{
+		providerOptions: opts,
+		baseURL:         models.LlamaCppEndpoint(opts.endpoint),
+		client:          &http.Client{},
+	}
+}
+
+type llamacppMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llamacppCompletionRequest struct {
+	Prompt      string `json:"prompt"`
+	NPredict    int64  `json:"n_predict,omitempty"`
+	Stream      bool   `json:"stream"`
+	CachePrompt bool   `json:"cache_prompt"`
+	Grammar     string `json:"grammar,omitempty"`
+}
+
+type llamacppCompletionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StoppedLimit    bool   `json:"stopped_limit"`
+	TokensEvaluated int64  `json:"tokens_evaluated"`
+	TokensPredicted int64  `json:"tokens_predicted"`
+}
+
+// llamacppError is an error response from the llama.cpp server.
+type llamacppError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *llamacppError) Error() string {
+	return fmt.Sprintf("llama.cpp: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// toolInstructions describes the tools and the reply format the grammar
+// enforces, since chat templates do not all know about tools.
+func toolInstructions(baseTools []tools.BaseTool) string {
+	var b strings.Builder
+	b.WriteString("\n\n# Tools\n\n")
+	b.WriteString("To call tools, reply only with one or more blocks of this form:\n")
+	b.WriteString(toolCallOpen + `{"name": <tool name>, "arguments": <arguments object>}` + toolCallClose)
+	b.WriteString("Tool results come back in <tool_response></tool_response> blocks. ")
+	b.WriteString("When no tool is needed, reply with plain text instead.\n")
+	for _, tool := range baseTools {
+		info := tool.Info()
+		schema, _ := json.Marshal(map[string]any{
+			"type":       "object",
+			"properties": info.Parameters,
+			"required":   info.Required,
+		})
+		fmt.Fprintf(&b, "\n## %s\n%s\nArguments schema: %s\n", info.Name, info.Description, schema)
+	}
+	return b.String()
+}
+
+func (l *llamacppClient) convertMessages(messages []message.Message, baseTools []tools.BaseTool) []llamacppMessage {
+	system := l.providerOptions.systemMessage
+	if len(baseTools) > 0 {
+		system += toolInstructions(baseTools)
+	}
+	llamacppMessages := []llamacppMessage{{Role: "system", Content: system}}
+	appendMessage := func(role, content string) {
+		// Some chat templates require the roles to alternate.
+		if last := &llamacppMessages[len(llamacppMessages)-1]; last.Role == role {
+			last.Content += "\n\n" + content
+			return
+		}
+		llamacppMessages = append(llamacppMessages, llamacppMessage{Role: role, Content: content})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			appendMessage("user", msg.Content().String())
+
+		case message.Assistant:
+			var b strings.Builder
+			b.WriteString(msg.Content().String())
+			for _, call := range msg.ToolCalls() {
+				arguments := call.Input
+				if !json.Valid([]byte(arguments)) {
+					arguments = "{}"
+				}
+				fmt.Fprintf(&b, "%s{\"name\": %s, \"arguments\": %s}%s", toolCallOpen, jsonString(call.Name), arguments, toolCallClose)
+			}
+			appendMessage("assistant", strings.TrimSpace(b.String()))
+
+		case message.Tool:
+			var b strings.Builder
+			for _, result := range msg.ToolResults() {
+				fmt.Fprintf(&b, "<tool_response>\n%s\n</tool_response>\n", result.Content)
+			}
+			appendMessage("user", strings.TrimSpace(b.String()))
+		}
+	}
+	return llamacppMessages
+}
+
+func (l *llamacppClient) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if cfg := config.Get(); cfg != nil && cfg.Debug {
+		logging.Debug("Prepared messages", "path", path, "body", string(data))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.providerOptions.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.providerOptions.apiKey)
+	}
+	res, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&body)
+		return nil, &llamacppError{StatusCode: res.StatusCode, Message: body.Error.Message}
+	}
+	return res, nil
+}
+
+// postWithRetry sends the request, waiting and retrying while the server is
+// loading the model or has no free slot.
+func (l *llamacppClient) postWithRetry(ctx context.Context, path string, body any) (*http.Response, error) {
+	attempts := 0
+	for {
+		attempts++
+		res, err := l.post(ctx, path, body)
+		if err == nil {
+			return res, nil
+		}
+		retry, after, retryErr := l.shouldRetry(attempts, err)
+		if !retry {
+			return nil, retryErr
+		}
+		logging.WarnPersist(fmt.Sprintf("Retrying due to busy server... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(after) * time.Millisecond):
+		}
+	}
+}
+
+// preparedRequest renders the conversation with the model's chat template and
+// constrains the reply with a grammar built from the tool schemas.
+func (l *llamacppClient) preparedRequest(ctx context.Context, messages []message.Message, baseTools []tools.BaseTool, stream bool) (llamacppCompletionRequest, error) {
+	res, err := l.postWithRetry(ctx, "/apply-template", map[string]any{"messages": l.convertMessages(messages, baseTools)})
+	if err != nil {
+		return llamacppCompletionRequest{}, err
+	}
+	defer res.Body.Close()
+	var template struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&template); err != nil {
+		return llamacppCompletionRequest{}, fmt.Errorf("failed to apply the chat template: %w", err)
+	}
+
+	req := llamacppCompletionRequest{
+		Prompt:      template.Prompt,
+		NPredict:    l.providerOptions.maxTokens,
+		Stream:      stream,
+		CachePrompt: true,
+	}
+	if len(baseTools) > 0 {
+		req.Grammar = toolCallGrammar(baseTools)
+	}
+	return req, nil
+}
+
+// parseToolCalls splits a reply into its text and the tool calls it makes.
+func parseToolCalls(reply string) (string, []message.ToolCall, error) {
+	if !strings.HasPrefix(reply, strings.TrimSpace(toolCallOpen)) {
+		return reply, nil, nil
+	}
+	var toolCalls []message.ToolCall
+	rest := reply
+	for {
+		start := strings.Index(rest, toolCallOpen)
+		if start < 0 {
+			break
+		}
+		rest = rest[start+len(toolCallOpen):]
+		end := strings.Index(rest, strings.TrimSpace(toolCallClose))
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated tool call: %s", rest)
+		}
+		var call struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal([]byte(rest[:end]), &call); err != nil {
+			return "", nil, fmt.Errorf("invalid tool call: %w", err)
+		}
+		var input bytes.Buffer
+		if err := json.Compact(&input, call.Arguments); err != nil {
+			return "", nil, fmt.Errorf("invalid tool call arguments: %w", err)
+		}
+		toolCalls = append(toolCalls, message.ToolCall{
+			ID:       "call_" + uuid.NewString(),
+			Name:     call.Name,
+			Input:    input.String(),
+			Type:     "function",
+			Finished: true,
+		})
+		rest = rest[end:]
+	}
+	return "", toolCalls, nil
+}
+
+func (l *llamacppClient) response(reply string, final llamacppCompletionResponse) (*ProviderResponse, error) {
+	content, toolCalls, err := parseToolCalls(reply)
+	if err != nil {
+		return nil, err
+	}
+	finishReason := message.FinishReasonEndTurn
+	if final.StoppedLimit {
+		finishReason = message.FinishReasonMaxTokens
+	}
+	if len(toolCalls) > 0 {
+		finishReason = message.FinishReasonToolUse
+	}
+	return &ProviderResponse{
+		Content:   content,
+		ToolCalls: toolCalls,
+		Usage: TokenUsage{
+			InputTokens:  final.TokensEvaluated,
+			OutputTokens: final.TokensPredicted,
+		},
+		FinishReason: finishReason,
+	}, nil
+}
+
+func (l *llamacppClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	req, err := l.preparedRequest(ctx, messages, tools, false)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.postWithRetry(ctx, "/completion", req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var completion llamacppCompletionResponse
+	if err := json.NewDecoder(res.Body).Decode(&completion); err != nil {
+		return nil, err
+	}
+	return l.response(completion.Content, completion)
+}
+
+func (l *llamacppClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		req, err := l.preparedRequest(ctx, messages, tools, true)
+		if err != nil {
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		res, err := l.postWithRetry(ctx, "/completion", req)
+		if err != nil {
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		defer res.Body.Close()
+
+		var reply strings.Builder
+		// Tool calls are only shown once complete, text is streamed.
+		toolCall := false
+		reader := bufio.NewReader(res.Body)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data: ")); ok {
+				var chunk llamacppCompletionResponse
+				if err := json.Unmarshal(data, &chunk); err != nil {
+					eventChan <- ProviderEvent{Type: EventError, Error: fmt.Errorf("failed to decode response: %w", err)}
+					return
+				}
+				if reply.Len() == 0 && strings.HasPrefix(chunk.Content, "<") {
+					toolCall = true
+				}
+				reply.WriteString(chunk.Content)
+				if chunk.Content != "" && !toolCall {
+					eventChan <- ProviderEvent{Type: EventContentDelta, Content: chunk.Content}
+				}
+				if chunk.Stop {
+					response, err := l.response(reply.String(), chunk)
+					if err != nil {
+						eventChan <- ProviderEvent{Type: EventError, Error: err}
+						return
+					}
+					eventChan <- ProviderEvent{Type: EventComplete, Response: response}
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				eventChan <- ProviderEvent{Type: EventError, Error: err}
+				return
+			}
+		}
+	}()
+
+	return eventChan
+}
+
+func (l *llamacppClient) shouldRetry(attempts int, err error) (bool, int64, error) {
+	var apierr *llamacppError
+	if !errors.As(err, &apierr) || apierr.StatusCode != http.StatusServiceUnavailable {
+		return false, 0, err
+	}
+	if attempts > maxRetries {
+		return false, 0, fmt.Errorf("%w for busy server: %d retries", ErrRetriesExhausted, maxRetries)
+	}
+	backoffMs := 2000 * (1 << (attempts - 1))
+	jitterMs := int(float64(backoffMs) * 0.2)
+	return true, int64(backoffMs + jitterMs), nil
+}
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/llm/tools"
+)
+
+// The reply format for tool calls. The grammar makes the model answer either
+// with plain text that does not start with "<", or with one or more of these
+// blocks holding a call that matches the tool's schema.
+const (
+	toolCallOpen  = "<tool_call>\n"
+	toolCallClose = "\n</tool_call>\n"
+)
+
+// JSON primitives, after llama.cpp's json-schema-to-grammar.
+const grammarPrimitives = `space ::= | " " | "\n" [ \t]{0,20}
+char ::= [^"\\\x7F\x00-\x1F] | [\\] (["\\bfnrt] | "u" [0-9a-fA-F]{4})
+string ::= "\"" char* "\"" space
+integer ::= ("-"? ([0-9] | [1-9] [0-9]{0,15})) space
+number ::= ("-"? ([0-9] | [1-9] [0-9]{0,15})) ("." [0-9]+)? ([eE] [-+]? [0-9]+)? space
+boolean ::= ("true" | "false") space
+null ::= "null" space
+value ::= object | array | string | number | boolean | null
+object ::= "{" space ( string ":" space value ("," space string ":" space value)* )? "}" space
+array ::= "[" space ( value ("," space value)* )? "]" space
+`
+
+var invalidRuleName = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+type grammarBuilder struct {
+	rules map[string]string
+	order []string
+}
+
+// toolCallGrammar returns the GBNF grammar for replies to a request offering
+// the tools.
+func toolCallGrammar(baseTools []tools.BaseTool) string {
+	g := &grammarBuilder{rules: make(map[string]string)}
+	calls := make([]string, 0, len(baseTools))
+	for _, tool := range baseTools {
+		info := tool.Info()
+		name := "call-" + ruleName(info.Name)
+		args := g.object(name+"-args", info.Parameters, info.Required)
+		calls = append(calls, g.add(name, gbnfLiteral(jsonString(info.Name)+", \"arguments\": ")+" "+args))
+	}
+	g.add("tool-call", gbnfLiteral(toolCallOpen+`{"name": `)+" ( "+strings.Join(calls, " | ")+" ) "+gbnfLiteral("}"+toolCallClose))
+	g.add("text", `[^<] [^\x00]*`)
+
+	var b strings.Builder
+	b.WriteString("root ::= tool-call+ | text\n")
+	for _, name := range g.order {
+		fmt.Fprintf(&b, "%s ::= %s\n", name, g.rules[name])
+	}
+	b.WriteString(grammarPrimitives)
+	return b.String()
+}
+
+// add defines a rule and returns its name, made unique if needed.
+func (g *grammarBuilder) add(name, body string) string {
+	unique := name
+	for i := 2; ; i++ {
+		existing, ok := g.rules[unique]
+		if !ok {
+			break
+		}
+		if existing == body {
+			return unique
+		}
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.rules[unique] = body
+	g.order = append(g.order, unique)
+	return unique
+}
+
+// schema returns the rule matching a JSON schema. Schemas it does not
+// understand accept any JSON value.
+func (g *grammarBuilder) schema(name string, schema map[string]any) string {
+	if enum, ok := schema["enum"]; ok {
+		var alternatives []string
+		for _, v := range anySlice(enum) {
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			alternatives = append(alternatives, gbnfLiteral(string(data)))
+		}
+		if len(alternatives) > 0 {
+			return g.add(name, "( "+strings.Join(alternatives, " | ")+" ) space")
+		}
+	}
+
+	switch schema["type"] {
+	case "string", "integer", "number", "boolean", "null":
+		return schema["type"].(string)
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		item := "value"
+		if items != nil {
+			item = g.schema(name+"-item", items)
+		}
+		return g.add(name, fmt.Sprintf(`"[" space ( %s ("," space %s)* )? "]" space`, item, item))
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+		return g.object(name, properties, anyStrings(schema["required"]))
+	default:
+		return "value"
+	}
+}
+
+// object returns the rule for an object with the properties. Required
+// properties come first in their given order and optional ones follow in
+// name order, so that every valid object has exactly one form.
+func (g *grammarBuilder) object(name string, properties map[string]any, required []string) string {
+	if len(properties) == 0 {
+		return g.add(name, `"{" space "}" space`)
+	}
+	var requiredKVs, optionalKVs []string
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kv := func(key string) string {
+		prop, _ := properties[key].(map[string]any)
+		propName := name + "-" + ruleName(key)
+		value := "value"
+		if prop != nil {
+			value = g.schema(propName, prop)
+		}
+		return g.add(propName+"-kv", gbnfLiteral(jsonString(key))+` space ":" space `+value)
+	}
+	for _, key := range required {
+		if _, ok := properties[key]; ok {
+			requiredKVs = append(requiredKVs, kv(key))
+		}
+	}
+	for _, key := range keys {
+		if !slices.Contains(required, key) {
+			optionalKVs = append(optionalKVs, kv(key))
+		}
+	}
+
+	optionalAfter := func(kvs []string) string {
+		var parts []string
+		for _, kv := range kvs {
+			parts = append(parts, `( "," space `+kv+` )?`)
+		}
+		return strings.Join(parts, " ")
+	}
+	var body string
+	if len(requiredKVs) > 0 {
+		body = strings.Join(requiredKVs, ` "," space `) + " " + optionalAfter(optionalKVs)
+	} else {
+		alternatives := make([]string, len(optionalKVs))
+		for i, kv := range optionalKVs {
+			alternatives[i] = strings.TrimSpace(kv + " " + optionalAfter(optionalKVs[i+1:]))
+		}
+		body = "( " + strings.Join(alternatives, " | ") + " )?"
+	}
+	return g.add(name, `"{" space `+strings.TrimSpace(body)+` "}" space`)
+}
+
+func ruleName(name string) string {
+	return strings.Trim(invalidRuleName.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// gbnfLiteral quotes s as a GBNF string literal.
+func gbnfLiteral(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func anySlice(v any) []any {
+	switch s := v.(type) {
+	case []any:
+		return s
+	case []string:
+		out := make([]any, len(s))
+		for i, item := range s {
+			out[i] = item
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func anyStrings(v any) []string {
+	var out []string
+	for _, item := range anySlice(v) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type grammarTestTool struct {
+	info tools.ToolInfo
+}
+
+func (t grammarTestTool) Info() tools.ToolInfo { return t.info }
+
+func (t grammarTestTool) Run(context.Context, tools.ToolCall) (tools.ToolResponse, error) {
+	return tools.ToolResponse{}, nil
+}
+
+var grammarTestTools = []tools.BaseTool{
+	grammarTestTool{tools.ToolInfo{
+		Name: "ls",
+		Parameters: map[string]any{
+			"path":   map[string]any{"type": "string"},
+			"ignore": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}},
+	grammarTestTool{tools.ToolInfo{
+		Name: "todo_write",
+		Parameters: map[string]any{
+			"todos": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"content": map[string]any{"type": "string"},
+						"status":  map[string]any{"type": "string", "enum": []string{"pending", "completed"}},
+					},
+					"required": []any{"content", "status"},
+				},
+			},
+		},
+		Required: []string{"todos"},
+	}},
+}
+
+func TestToolCallGrammar(t *testing.T) {
+	t.Parallel()
+
+	grammar := toolCallGrammar(grammarTestTools)
+
+	// Every rule that is referenced is defined.
+	defined := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(grammar), "\n") {
+		name, _, ok := strings.Cut(line, " ::= ")
+		require.True(t, ok, line)
+		assert.False(t, defined[name], "rule %s defined twice", name)
+		defined[name] = true
+	}
+	literals := regexp.MustCompile(`"(\\.|[^"\\])*"|\[(\\.|[^\]\\])*\]|\{\d+,\d+\}`)
+	for _, line := range strings.Split(strings.TrimSpace(grammar), "\n") {
+		_, body, _ := strings.Cut(line, " ::= ")
+		for _, ref := range regexp.MustCompile(`[a-z][a-z0-9-]*`).FindAllString(literals.ReplaceAllString(body, ""), -1) {
+			assert.True(t, defined[ref], "rule %s is not defined, in %s", ref, line)
+		}
+	}
+
+	assert.Contains(t, grammar, `root ::= tool-call+ | text`)
+	assert.Contains(t, grammar, `call-ls-args ::= "{" space ( call-ls-args-ignore-kv ( "," space call-ls-args-path-kv )? | call-ls-args-path-kv )? "}" space`)
+	assert.Contains(t, grammar, `call-todo-write-args-todos-item-status ::= ( "\"pending\"" | "\"completed\"" ) space`)
+	assert.Contains(t, grammar, `call-todo-write-args-todos-item ::= "{" space call-todo-write-args-todos-item-content-kv "," space call-todo-write-args-todos-item-status-kv "}" space`)
+}
+
+func TestParseToolCalls(t *testing.T) {
+	t.Parallel()
+
+	content, calls, err := parseToolCalls("<tool_call>\n{\"name\": \"ls\", \"arguments\": {\"path\": \".\"}}\n</tool_call>\n<tool_call>\n{\"name\": \"view\", \"arguments\": {\n  \"file_path\": \"a\"\n}}\n</tool_call>\n")
+	require.NoError(t, err)
+	assert.Empty(t, content)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "ls", calls[0].Name)
+	assert.Equal(t, `{"path":"."}`, calls[0].Input)
+	assert.Equal(t, "view", calls[1].Name)
+	assert.Equal(t, `{"file_path":"a"}`, calls[1].Input)
+	assert.NotEqual(t, calls[0].ID, calls[1].ID)
+
+	content, calls, err = parseToolCalls("Done.")
+	require.NoError(t, err)
+	assert.Equal(t, "Done.", content)
+	assert.Empty(t, calls)
+}
+
+func TestLlamaCppClient_Stream(t *testing.T) {
+	t.Parallel()
+
+	var templated map[string][]llamacppMessage
+	var completion llamacppCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apply-template":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&templated))
+			fmt.Fprint(w, `{"prompt":"<prompt>"}`)
+		case "/completion":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&completion))
+			for _, chunk := range []string{"<tool_call>\n", `{"name": "ls", "arguments": {"path": "."}}`, "\n</tool_call>\n"} {
+				data, _ := json.Marshal(map[string]any{"content": chunk, "stop": false})
+				fmt.Fprintf(w, "data: %s\n\n", data)
+			}
+			fmt.Fprint(w, "data: {\"content\":\"\",\"stop\":true,\"tokens_evaluated\":120,\"tokens_predicted\":20}\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(models.ProviderLlamaCpp,
+		WithModel(models.Model{Provider: models.ProviderLlamaCpp}),
+		WithSystemMessage("system"),
+		WithMaxTokens(512),
+		WithEndpoint(server.URL+"/v1"),
+	)
+	require.NoError(t, err)
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "list files"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call_1", Name: "ls", Input: `{"path":"a"}`}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call_1", Content: "b.go"}}},
+	}
+	events := collectEvents(p.StreamResponse(context.Background(), history, grammarTestTools))
+
+	require.Len(t, events, 1, "tool call text is not streamed")
+	require.Equal(t, EventComplete, events[0].Type)
+	resp := events[0].Response
+	assert.Equal(t, message.FinishReasonToolUse, resp.FinishReason)
+	assert.Equal(t, TokenUsage{InputTokens: 120, OutputTokens: 20}, resp.Usage)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, `{"path":"."}`, resp.ToolCalls[0].Input)
+
+	msgs := templated["messages"]
+	require.Len(t, msgs, 4)
+	assert.Contains(t, msgs[0].Content, "## todo_write")
+	assert.Equal(t, "<tool_call>\n{\"name\": \"ls\", \"arguments\": {\"path\":\"a\"}}\n</tool_call>", msgs[2].Content)
+	assert.Equal(t, "<tool_response>\nb.go\n</tool_response>", msgs[3].Content)
+	assert.Equal(t, "<prompt>", completion.Prompt)
+	assert.EqualValues(t, 512, completion.NPredict)
+	assert.Equal(t, toolCallGrammar(grammarTestTools), completion.Grammar)
+}
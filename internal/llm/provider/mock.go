@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/message"
+)
+
+// defaultMockScript is used when a fixture has no script for the requested name.
+const defaultMockScript = "default"
+
+// ErrMockScriptExhausted is returned when a client is called more times than it has responses.
+var ErrMockScriptExhausted = errors.New("mock script exhausted")
+
+// MockToolCall is a tool call the mock model requests.
+type MockToolCall struct {
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// MockUsage is the token usage reported for a scripted response.
+type MockUsage struct {
+	InputTokens         int64 `json:"inputTokens"`
+	OutputTokens        int64 `json:"outputTokens"`
+	CacheCreationTokens int64 `json:"cacheCreationTokens"`
+	CacheReadTokens     int64 `json:"cacheReadTokens"`
+}
+
+// MockResponse is one scripted provider call. Thinking and Content are emitted
+// as separate deltas, in order, before any tool calls.
+type MockResponse struct {
+	Thinking     []string             `json:"thinking,omitempty"`
+	Content      []string             `json:"content,omitempty"`
+	ToolCalls    []MockToolCall       `json:"toolCalls,omitempty"`
+	Usage        MockUsage            `json:"usage"`
+	FinishReason message.FinishReason `json:"finishReason,omitempty"`
+	Error        string               `json:"error,omitempty"`
+	DelayMs      int                  `json:"delayMs,omitempty"`
+}
+
+// MockFixture maps a script name (usually an agent name) to its responses.
+type MockFixture map[string][]MockResponse
+
+type mockOptions struct {
+	fixturePath string
+	scriptName  string
+	responses   []MockResponse
+	recorder    func(messages []message.Message, tools []tools.BaseTool)
+}
+
+type MockOption func(*mockOptions)
+
+type mockClient struct {
+	providerOptions providerClientOptions
+	options         mockOptions
+
+	mu        sync.Mutex
+	responses []MockResponse
+	next      int
+	loadErr   error
+}
+
+type MockClient ProviderClient
+
+func newMockClient(opts providerClientOptions) MockClient {
+	mockOpts := mockOptions{
+		scriptName: defaultMockScript,
+	}
+	for _, o := range opts.mockOptions {
+		o(&mockOpts)
+	}
+
+	client := &mockClient{
+		providerOptions: opts,
+		options:         mockOpts,
+		responses:       mockOpts.responses,
+	}
+	if client.responses == nil && mockOpts.fixturePath != "" {
+		client.responses, client.loadErr = loadMockScript(mockOpts.fixturePath, mockOpts.scriptName)
+	}
+	return client
+}
+
+// LoadMockFixture reads a fixture file from disk.
+func LoadMockFixture(path string) (MockFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock fixture: %w", err)
+	}
+	var fixture MockFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse mock fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func loadMockScript(path, name string) ([]MockResponse, error) {
+	fixture, err := LoadMockFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	if responses, ok := fixture[name]; ok {
+		return responses, nil
+	}
+	return fixture[defaultMockScript], nil
+}
+
+func (m *mockClient) nextResponse(messages []message.Message, tools []tools.BaseTool) (MockResponse, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.options.recorder != nil {
+		m.options.recorder(messages, tools)
+	}
+	if m.loadErr != nil {
+		return MockResponse{}, 0, m.loadErr
+	}
+	if m.next >= len(m.responses) {
+		return MockResponse{}, 0, fmt.Errorf("%w: script %q has %d responses", ErrMockScriptExhausted, m.options.scriptName, len(m.responses))
+	}
+	index := m.next
+	m.next++
+	return m.responses[index], index, nil
+}
+
+func (m *mockClient) toolCalls(resp MockResponse, index int) []message.ToolCall {
+	toolCalls := make([]message.ToolCall, 0, len(resp.ToolCalls))
+	for i, call := range resp.ToolCalls {
+		id := call.ID
+		if id == "" {
+			id = fmt.Sprintf("mock-call-%d-%d", index, i)
+		}
+		input := strings.TrimSpace(string(call.Input))
+		if input == "" {
+			input = "{}"
+		}
+		toolCalls = append(toolCalls, message.ToolCall{
+			ID:       id,
+			Name:     call.Name,
+			Input:    input,
+			Type:     "function",
+			Finished: true,
+		})
+	}
+	return toolCalls
+}
+
+func (m *mockClient) finishReason(resp MockResponse) message.FinishReason {
+	if resp.FinishReason != "" {
+		return resp.FinishReason
+	}
+	if len(resp.ToolCalls) > 0 {
+		return message.FinishReasonToolUse
+	}
+	return message.FinishReasonEndTurn
+}
+
+func (m *mockClient) usage(resp MockResponse) TokenUsage {
+	return TokenUsage{
+		InputTokens:         resp.Usage.InputTokens,
+		OutputTokens:        resp.Usage.OutputTokens,
+		CacheCreationTokens: resp.Usage.CacheCreationTokens,
+		CacheReadTokens:     resp.Usage.CacheReadTokens,
+	}
+}
+
+func (m *mockClient) response(resp MockResponse, index int) *ProviderResponse {
+	return &ProviderResponse{
+		Content:      strings.Join(resp.Content, ""),
+		ToolCalls:    m.toolCalls(resp, index),
+		Usage:        m.usage(resp),
+		FinishReason: m.finishReason(resp),
+	}
+}
+
+func (m *mockClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	resp, index, err := m.nextResponse(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.response(resp, index), nil
+}
+
+func (m *mockClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+
+		resp, index, err := m.nextResponse(messages, tools)
+		if err != nil {
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+
+		// emit sends an event unless the context is cancelled first, in which
+		// case the cancellation is reported the same way the real clients do.
+		emit := func(event ProviderEvent) bool {
+			if resp.DelayMs > 0 {
+				select {
+				case <-ctx.Done():
+					eventChan <- ProviderEvent{Type: EventError, Error: ctx.Err()}
+					return false
+				case <-time.After(time.Duration(resp.DelayMs) * time.Millisecond):
+				}
+			} else if ctx.Err() != nil {
+				eventChan <- ProviderEvent{Type: EventError, Error: ctx.Err()}
+				return false
+			}
+			eventChan <- event
+			return true
+		}
+
+		for _, thinking := range resp.Thinking {
+			if !emit(ProviderEvent{Type: EventThinkingDelta, Content: thinking, Thinking: thinking}) {
+				return
+			}
+		}
+		if len(resp.Content) > 0 {
+			if !emit(ProviderEvent{Type: EventContentStart}) {
+				return
+			}
+			for _, delta := range resp.Content {
+				if !emit(ProviderEvent{Type: EventContentDelta, Content: delta}) {
+					return
+				}
+			}
+			if !emit(ProviderEvent{Type: EventContentStop}) {
+				return
+			}
+		}
+		if resp.Error != "" {
+			emit(ProviderEvent{Type: EventError, Error: errors.New(resp.Error)})
+			return
+		}
+
+		response := m.response(resp, index)
+		for _, call := range response.ToolCalls {
+			if !emit(ProviderEvent{
+				Type: EventToolUseStart,
+				ToolCall: &message.ToolCall{
+					ID:       call.ID,
+					Name:     call.Name,
+					Finished: false,
+				},
+			}) {
+				return
+			}
+			if !emit(ProviderEvent{Type: EventToolUseStop, ToolCall: &message.ToolCall{ID: call.ID}}) {
+				return
+			}
+		}
+		emit(ProviderEvent{Type: EventComplete, Response: response})
+	}()
+
+	return eventChan
+}
+
+// WithMockFixture loads the responses from a fixture file.
+func WithMockFixture(path string) MockOption {
+	return func(options *mockOptions) {
+		options.fixturePath = path
+	}
+}
+
+// WithMockScriptName selects which script of the fixture this client replays.
+func WithMockScriptName(name string) MockOption {
+	return func(options *mockOptions) {
+		options.scriptName = name
+	}
+}
+
+// WithMockResponses scripts the responses directly, bypassing any fixture file.
+func WithMockResponses(responses ...MockResponse) MockOption {
+	return func(options *mockOptions) {
+		options.responses = responses
+	}
+}
+
+// WithMockRecorder registers a callback that receives every request made to the client.
+func WithMockRecorder(recorder func(messages []message.Message, tools []tools.BaseTool)) MockOption {
+	return func(options *mockOptions) {
+		options.recorder = recorder
+	}
+}
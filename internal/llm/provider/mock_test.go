@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMockProvider(t *testing.T, opts ...MockOption) Provider {
+	t.Helper()
+	p, err := NewProvider(models.ProviderMock,
+		WithModel(models.SupportedModels[models.MockScripted]),
+		WithMockOptions(opts...),
+	)
+	require.NoError(t, err)
+	return p
+}
+
+func collectEvents(ch <-chan ProviderEvent) []ProviderEvent {
+	var events []ProviderEvent
+	for event := range ch {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestMockClient_StreamReplaysScript(t *testing.T) {
+	t.Parallel()
+
+	p := newTestMockProvider(t, WithMockResponses(
+		MockResponse{
+			Thinking: []string{"hmm"},
+			Content:  []string{"Hello", ", world"},
+			ToolCalls: []MockToolCall{
+				{Name: "ls", Input: []byte(`{"path":"."}`)},
+			},
+			Usage: MockUsage{InputTokens: 10, OutputTokens: 5},
+		},
+	))
+
+	userMsg := message.Message{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "hi"}}}
+	events := collectEvents(p.StreamResponse(context.Background(), []message.Message{userMsg}, nil))
+
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []EventType{
+		EventThinkingDelta,
+		EventContentStart,
+		EventContentDelta,
+		EventContentDelta,
+		EventContentStop,
+		EventToolUseStart,
+		EventToolUseStop,
+		EventComplete,
+	}, types)
+
+	resp := events[len(events)-1].Response
+	require.NotNil(t, resp)
+	assert.Equal(t, "Hello, world", resp.Content)
+	assert.Equal(t, message.FinishReasonToolUse, resp.FinishReason)
+	assert.Equal(t, int64(10), resp.Usage.InputTokens)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "mock-call-0-0", resp.ToolCalls[0].ID)
+	assert.Equal(t, `{"path":"."}`, resp.ToolCalls[0].Input)
+}
+
+func TestMockClient_ErrorsAndExhaustion(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	p := newTestMockProvider(t,
+		WithMockResponses(MockResponse{Error: "boom"}),
+		WithMockRecorder(func([]message.Message, []tools.BaseTool) { requests++ }),
+	)
+
+	_, err := p.SendMessages(context.Background(), nil, nil)
+	assert.EqualError(t, err, "boom")
+
+	events := collectEvents(p.StreamResponse(context.Background(), nil, nil))
+	require.Len(t, events, 1)
+	assert.Equal(t, EventError, events[0].Type)
+	assert.ErrorIs(t, events[0].Error, ErrMockScriptExhausted)
+	assert.Equal(t, 2, requests)
+}
+
+func TestMockClient_FixtureScriptSelection(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := `{
+		"default": [{"content": ["from default"]}],
+		"title": [{"content": ["A title"]}]
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+
+	title := newTestMockProvider(t, WithMockFixture(path), WithMockScriptName("title"))
+	resp, err := title.SendMessages(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "A title", resp.Content)
+	assert.Equal(t, message.FinishReasonEndTurn, resp.FinishReason)
+
+	coder := newTestMockProvider(t, WithMockFixture(path), WithMockScriptName("coder"))
+	resp, err = coder.SendMessages(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from default", resp.Content)
+}
@@ -0,0 +1,391 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/llm/tools"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/google/uuid"
+)
+
+type ollamaOptions struct {
+	baseURL   string
+	keepAlive string
+	numCtx    int64
+}
+
+type OllamaOption func(*ollamaOptions)
+
+type ollamaClient struct {
+	providerOptions providerClientOptions
+	options         ollamaOptions
+	client          *http.Client
+}
+
+type OllamaClient ProviderClient
+
+func newOllamaClient(opts providerClientOptions) OllamaClient {
+	ollamaOpts := ollamaOptions{}
+	for _, o := range opts.ollamaOptions {
+		o(&ollamaOpts)
+	}
+	ollamaOpts.baseURL = models.OllamaEndpoint(ollamaOpts.baseURL)
+	return &ollamaClient{
+		providerOptions: opts,
+		options:         ollamaOpts,
+		client:          &http.Client{},
+	}
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Tools     []ollamaTool    `json:"tools,omitempty"`
+	Stream    bool            `json:"stream"`
+	Think     *bool           `json:"think,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int64         `json:"prompt_eval_count"`
+	EvalCount       int64         `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// ollamaError is an error response from the Ollama server.
+type ollamaError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ollamaError) Error() string {
+	return fmt.Sprintf("ollama: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (o *ollamaClient) convertMessages(messages []message.Message) []ollamaMessage {
+	ollamaMessages := []ollamaMessage{{Role: "system", Content: o.providerOptions.systemMessage}}
+
+	// Ollama matches tool results to calls by tool name, not by ID.
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		switch msg.Role {
+		case message.User:
+			userMsg := ollamaMessage{Role: "user", Content: msg.Content().String()}
+			if o.providerOptions.model.SupportsAttachments {
+				for _, binaryContent := range msg.BinaryContent() {
+					userMsg.Images = append(userMsg.Images, base64.StdEncoding.EncodeToString(binaryContent.Data))
+				}
+			}
+			ollamaMessages = append(ollamaMessages, userMsg)
+
+		case message.Assistant:
+			assistantMsg := ollamaMessage{Role: "assistant", Content: msg.Content().String()}
+			for _, call := range msg.ToolCalls() {
+				toolNames[call.ID] = call.Name
+				var toolCall ollamaToolCall
+				toolCall.Function.Name = call.Name
+				toolCall.Function.Arguments = json.RawMessage("{}")
+				if json.Valid([]byte(call.Input)) {
+					toolCall.Function.Arguments = json.RawMessage(call.Input)
+				}
+				assistantMsg.ToolCalls = append(assistantMsg.ToolCalls, toolCall)
+			}
+			ollamaMessages = append(ollamaMessages, assistantMsg)
+
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				ollamaMessages = append(ollamaMessages, ollamaMessage{
+					Role:     "tool",
+					Content:  result.Content,
+					ToolName: toolNames[result.ToolCallID],
+				})
+			}
+		}
+	}
+	return ollamaMessages
+}
+
+func (o *ollamaClient) convertTools(tools []tools.BaseTool) []ollamaTool {
+	if o.providerOptions.model.NoTools {
+		return nil
+	}
+	ollamaTools := make([]ollamaTool, len(tools))
+	for i, tool := range tools {
+		info := tool.Info()
+		ollamaTools[i].Type = "function"
+		ollamaTools[i].Function.Name = info.Name
+		ollamaTools[i].Function.Description = info.Description
+		ollamaTools[i].Function.Parameters = map[string]any{
+			"type":       "object",
+			"properties": info.Parameters,
+			"required":   info.Required,
+		}
+	}
+	return ollamaTools
+}
+
+func (o *ollamaClient) preparedRequest(ctx context.Context, messages []message.Message, tools []tools.BaseTool, stream bool) ollamaChatRequest {
+	req := ollamaChatRequest{
+		Model:     o.providerOptions.model.APIModel,
+		Messages:  o.convertMessages(messages),
+		Tools:     o.convertTools(tools),
+		Stream:    stream,
+		KeepAlive: o.options.keepAlive,
+		Options:   map[string]any{},
+	}
+	if o.providerOptions.model.CanReason {
+		think := thinkFromContext(ctx)
+		req.Think = &think
+	}
+	if o.options.numCtx > 0 {
+		req.Options["num_ctx"] = o.options.numCtx
+	}
+	if o.providerOptions.maxTokens > 0 {
+		req.Options["num_predict"] = o.providerOptions.maxTokens
+	}
+	return req
+}
+
+func (o *ollamaClient) post(ctx context.Context, req ollamaChatRequest) (*http.Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if cfg := config.Get(); cfg != nil && cfg.Debug {
+		logging.Debug("Prepared messages", "messages", string(data))
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.options.baseURL+"/api/chat", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	res, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&body)
+		return nil, &ollamaError{StatusCode: res.StatusCode, Message: body.Error}
+	}
+	return res, nil
+}
+
+// postWithRetry sends the request, waiting and retrying while the server is
+// busy.
+func (o *ollamaClient) postWithRetry(ctx context.Context, req ollamaChatRequest) (*http.Response, error) {
+	attempts := 0
+	for {
+		attempts++
+		res, err := o.post(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+		retry, after, retryErr := o.shouldRetry(attempts, err)
+		if !retry {
+			return nil, retryErr
+		}
+		logging.WarnPersist(fmt.Sprintf("Retrying due to busy server... attempt %d of %d", attempts, maxRetries), logging.PersistTimeArg, time.Millisecond*time.Duration(after+100))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(after) * time.Millisecond):
+		}
+	}
+}
+
+func (o *ollamaClient) finishReason(reason string) message.FinishReason {
+	switch reason {
+	case "stop":
+		return message.FinishReasonEndTurn
+	case "length":
+		return message.FinishReasonMaxTokens
+	default:
+		return message.FinishReasonUnknown
+	}
+}
+
+func (o *ollamaClient) toolCalls(calls []ollamaToolCall) []message.ToolCall {
+	var toolCalls []message.ToolCall
+	for _, call := range calls {
+		toolCalls = append(toolCalls, message.ToolCall{
+			// Ollama does not assign IDs to tool calls.
+			ID:       "call_" + uuid.NewString(),
+			Name:     call.Function.Name,
+			Input:    string(call.Function.Arguments),
+			Type:     "function",
+			Finished: true,
+		})
+	}
+	return toolCalls
+}
+
+func (o *ollamaClient) response(content string, toolCalls []message.ToolCall, final ollamaChatResponse) *ProviderResponse {
+	finishReason := o.finishReason(final.DoneReason)
+	if len(toolCalls) > 0 {
+		finishReason = message.FinishReasonToolUse
+	}
+	return &ProviderResponse{
+		Content:   content,
+		ToolCalls: toolCalls,
+		Usage: TokenUsage{
+			InputTokens:  final.PromptEvalCount,
+			OutputTokens: final.EvalCount,
+		},
+		FinishReason: finishReason,
+	}
+}
+
+func (o *ollamaClient) send(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error) {
+	res, err := o.postWithRetry(ctx, o.preparedRequest(ctx, messages, tools, false))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var chat ollamaChatResponse
+	if err := json.NewDecoder(res.Body).Decode(&chat); err != nil {
+		return nil, err
+	}
+	if chat.Error != "" {
+		return nil, errors.New(chat.Error)
+	}
+	return o.response(chat.Message.Content, o.toolCalls(chat.Message.ToolCalls), chat), nil
+}
+
+func (o *ollamaClient) stream(ctx context.Context, messages []message.Message, tools []tools.BaseTool) <-chan ProviderEvent {
+	req := o.preparedRequest(ctx, messages, tools, true)
+	eventChan := make(chan ProviderEvent)
+
+	go func() {
+		defer close(eventChan)
+		res, err := o.postWithRetry(ctx, req)
+		if err != nil {
+			eventChan <- ProviderEvent{Type: EventError, Error: err}
+			return
+		}
+		defer res.Body.Close()
+
+		currentContent := ""
+		var toolCalls []message.ToolCall
+		reader := bufio.NewReader(res.Body)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				var chunk ollamaChatResponse
+				if err := json.Unmarshal(line, &chunk); err != nil {
+					eventChan <- ProviderEvent{Type: EventError, Error: fmt.Errorf("failed to decode response: %w", err)}
+					return
+				}
+				if chunk.Error != "" {
+					eventChan <- ProviderEvent{Type: EventError, Error: errors.New(chunk.Error)}
+					return
+				}
+				if chunk.Message.Thinking != "" {
+					eventChan <- ProviderEvent{Type: EventThinkingDelta, Content: chunk.Message.Thinking, Thinking: chunk.Message.Thinking}
+				}
+				if chunk.Message.Content != "" {
+					eventChan <- ProviderEvent{Type: EventContentDelta, Content: chunk.Message.Content}
+					currentContent += chunk.Message.Content
+				}
+				toolCalls = append(toolCalls, o.toolCalls(chunk.Message.ToolCalls)...)
+				if chunk.Done {
+					eventChan <- ProviderEvent{Type: EventComplete, Response: o.response(currentContent, toolCalls, chunk)}
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				eventChan <- ProviderEvent{Type: EventError, Error: err}
+				return
+			}
+		}
+	}()
+
+	return eventChan
+}
+
+func (o *ollamaClient) shouldRetry(attempts int, err error) (bool, int64, error) {
+	var apierr *ollamaError
+	if !errors.As(err, &apierr) {
+		return false, 0, err
+	}
+	if apierr.StatusCode != http.StatusTooManyRequests && apierr.StatusCode != http.StatusServiceUnavailable {
+		return false, 0, err
+	}
+	if attempts > maxRetries {
+		return false, 0, fmt.Errorf("%w for busy server: %d retries", ErrRetriesExhausted, maxRetries)
+	}
+	backoffMs := 2000 * (1 << (attempts - 1))
+	jitterMs := int(float64(backoffMs) * 0.2)
+	return true, int64(backoffMs + jitterMs), nil
+}
+
+func WithOllamaBaseURL(baseURL string) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.baseURL = baseURL
+	}
+}
+
+// WithOllamaKeepAlive sets how long the model stays loaded after a request,
+// e.g. "30m". An empty value leaves the server default.
+func WithOllamaKeepAlive(keepAlive string) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.keepAlive = keepAlive
+	}
+}
+
+// WithOllamaNumCtx sets the context length the model is loaded with.
+func WithOllamaNumCtx(numCtx int64) OllamaOption {
+	return func(options *ollamaOptions) {
+		options.numCtx = numCtx
+	}
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cap-ai/cap/internal/llm/models"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaClient_Stream(t *testing.T) {
+	t.Parallel()
+
+	var received ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/chat", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","thinking":"hmm"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"ls","arguments":{"path":"."}}}]},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":42,"eval_count":7}`)
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(models.ProviderOllama,
+		WithModel(models.Model{ID: "ollama.qwen3:8b", Provider: models.ProviderOllama, APIModel: "qwen3:8b", CanReason: true}),
+		WithMaxTokens(1024),
+		WithEndpoint(server.URL),
+		WithOllamaOptions(WithOllamaKeepAlive("30m"), WithOllamaNumCtx(16384)),
+	)
+	require.NoError(t, err)
+
+	history := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "list files"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call_1", Name: "view", Input: `{"file_path":"a"}`}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call_1", Content: "content"}}},
+	}
+	events := collectEvents(p.StreamResponse(WithThink(context.Background(), true), history, nil))
+
+	require.Len(t, events, 3)
+	assert.Equal(t, EventThinkingDelta, events[0].Type)
+	assert.Equal(t, "hmm", events[0].Thinking)
+	assert.Equal(t, EventContentDelta, events[1].Type)
+	require.Equal(t, EventComplete, events[2].Type)
+	resp := events[2].Response
+	assert.Equal(t, "Hello", resp.Content)
+	assert.Equal(t, message.FinishReasonToolUse, resp.FinishReason)
+	assert.Equal(t, TokenUsage{InputTokens: 42, OutputTokens: 7}, resp.Usage)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "ls", resp.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"path":"."}`, resp.ToolCalls[0].Input)
+	assert.NotEmpty(t, resp.ToolCalls[0].ID)
+
+	assert.Equal(t, "qwen3:8b", received.Model)
+	require.NotNil(t, received.Think)
+	assert.True(t, *received.Think)
+	assert.Equal(t, "30m", received.KeepAlive)
+	assert.EqualValues(t, 16384, received.Options["num_ctx"])
+	assert.EqualValues(t, 1024, received.Options["num_predict"])
+	require.Len(t, received.Messages, 4)
+	assert.Equal(t, "system", received.Messages[0].Role)
+	assert.Equal(t, "tool", received.Messages[3].Role)
+	assert.Equal(t, "view", received.Messages[3].ToolName)
+}
+
+func TestOllamaClient_ServerError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(models.ProviderOllama,
+		WithModel(models.Model{Provider: models.ProviderOllama, APIModel: "missing"}),
+		WithEndpoint(server.URL),
+	)
+	require.NoError(t, err)
+
+	_, err = p.SendMessages(context.Background(), nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try pulling it first")
+	assert.False(t, ShouldFailover(err))
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,6 +15,10 @@ type EventType string
 
 const maxRetries = 8
 
+// ErrRetriesExhausted is returned once a client gave up retrying a rate
+// limited or overloaded provider.
+var ErrRetriesExhausted = errors.New("maximum retry attempts reached")
+
 const (
 	EventContentStart  EventType = "content_start"
 	EventToolUseStart  EventType = "tool_use_start"
@@ -25,6 +30,8 @@ const (
 	EventComplete      EventType = "complete"
 	EventError         EventType = "error"
 	EventWarning       EventType = "warning"
+	// EventFailover is sent when a fallback model takes over the request.
+	EventFailover EventType = "failover"
 )
 
 type TokenUsage struct {
@@ -49,7 +56,22 @@ type ProviderEvent struct {
 	Response *ProviderResponse
 	ToolCall *message.ToolCall
 	Error    error
+	// Model is the model that took over, set for EventFailover.
+	Model models.ModelID
+}
+type thinkContextKey struct{}
+
+// WithThink returns a context asking providers that switch thinking on and off
+// per request, such as Ollama, whether to think. Without it they do not.
+func WithThink(ctx context.Context, think bool) context.Context {
+	return context.WithValue(ctx, thinkContextKey{}, think)
+}
+
+func thinkFromContext(ctx context.Context) bool {
+	think, _ := ctx.Value(thinkContextKey{}).(bool)
+	return think
 }
+
 type Provider interface {
 	SendMessages(ctx context.Context, messages []message.Message, tools []tools.BaseTool) (*ProviderResponse, error)
 
@@ -68,6 +90,8 @@ type providerClientOptions struct {
 	openaiOptions    []OpenAIOption
 	geminiOptions    []GeminiOption
 	bedrockOptions   []BedrockOption
+	ollamaOptions    []OllamaOption
+	mockOptions      []MockOption
 
 	// 2025.06.14 Kawata added endpoint for provider
 	endpoint string
@@ -165,9 +189,40 @@ func NewProvider(providerName models.ModelProvider, opts ...ProviderClientOption
 			options: clientOptions,
 			client:  newOpenAIClient(clientOptions),
 		}, nil
+	case models.ProviderOllama:
+		clientOptions.ollamaOptions = append([]OllamaOption{WithOllamaBaseURL(clientOptions.endpoint)}, clientOptions.ollamaOptions...)
+		return &baseProvider[OllamaClient]{
+			options: clientOptions,
+			client:  newOllamaClient(clientOptions),
+		}, nil
+	case models.ProviderLlamaCpp:
+		return &baseProvider[LlamaCppClient]{
+			options: clientOptions,
+			client:  newLlamaCppClient(clientOptions),
+		}, nil
 	case models.ProviderMock:
-		// TODO: implement mock client for test
-		panic("not implemented")
+		// The fixture path comes from CAP_MOCK_FIXTURE or the provider endpoint,
+		// mirroring how the local provider resolves its endpoint.
+		fixturePath := os.Getenv("CAP_MOCK_FIXTURE")
+		if fixturePath == "" {
+			fixturePath = clientOptions.endpoint
+		}
+		if fixturePath != "" {
+			clientOptions.mockOptions = append([]MockOption{WithMockFixture(fixturePath)}, clientOptions.mockOptions...)
+		}
+		return &baseProvider[MockClient]{
+			options: clientOptions,
+			client:  newMockClient(clientOptions),
+		}, nil
+	}
+	if models.IsCustomProvider(providerName) {
+		clientOptions.openaiOptions = append(clientOptions.openaiOptions,
+			WithOpenAIBaseURL(clientOptions.endpoint),
+		)
+		return &baseProvider[OpenAIClient]{
+			options: clientOptions,
+			client:  newOpenAIClient(clientOptions),
+		}, nil
 	}
 	return nil, fmt.Errorf("provider not supported: %s", providerName)
 }
@@ -245,6 +300,18 @@ func WithBedrockOptions(bedrockOptions ...BedrockOption) ProviderClientOption {
 	}
 }
 
+func WithOllamaOptions(ollamaOptions ...OllamaOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.ollamaOptions = ollamaOptions
+	}
+}
+
+func WithMockOptions(mockOptions ...MockOption) ProviderClientOption {
+	return func(options *providerClientOptions) {
+		options.mockOptions = mockOptions
+	}
+}
+
 // 2025.06.14 Kawata added endpoint for provider
 func WithEndpoint(endpoint string) ProviderClientOption {
 	return func(options *providerClientOptions) {
@@ -266,20 +266,21 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
 	}
-	if !isSafeReadOnly {
-		p := b.permissions.Request(
-			permission.CreatePermissionRequest{
-				SessionID:   sessionID,
-				Path:        config.WorkingDirectory(),
-				ToolName:    BashToolName,
-				Action:      "execute",
-				Description: fmt.Sprintf("Execute command: %s", params.Command),
-				Params: BashPermissionsParams{
-					Command: params.Command,
-				},
-			},
-		)
-		if !p {
+	permissionRequest := permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        config.WorkingDirectory(),
+		ToolName:    BashToolName,
+		Action:      "execute",
+		Description: fmt.Sprintf("Execute command: %s", params.Command),
+		Params: BashPermissionsParams{
+			Command: params.Command,
+		},
+		Command: params.Command,
+	}
+	// Read-only commands run without asking unless a permission rule matches them,
+	// so that rules such as denying **/.env also cover "cat .env".
+	if !isSafeReadOnly || b.permissions.Evaluate(permissionRequest) != "" {
+		if !b.permissions.Request(permissionRequest) {
 			return ToolResponse{}, permission.ErrorPermissionDenied
 		}
 	}
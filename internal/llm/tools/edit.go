@@ -227,6 +227,7 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string)
 				FilePath: filePath,
 				Diff:     diff,
 			},
+			FilePath: filePath,
 		},
 	)
 	if !p {
@@ -335,6 +336,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 				FilePath: filePath,
 				Diff:     diff,
 			},
+			FilePath: filePath,
 		},
 	)
 	if !p {
@@ -452,6 +454,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 				FilePath: filePath,
 				Diff:     diff,
 			},
+			FilePath: filePath,
 		},
 	)
 	if !p {
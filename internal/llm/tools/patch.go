@@ -212,6 +212,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 						FilePath: path,
 						Diff:     patchDiff,
 					},
+					FilePath: path,
 				},
 			)
 			if !p {
@@ -239,6 +240,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 						FilePath: path,
 						Diff:     patchDiff,
 					},
+					FilePath: path,
 				},
 			)
 			if !p {
@@ -258,6 +260,7 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 						FilePath: path,
 						Diff:     patchDiff,
 					},
+					FilePath: path,
 				},
 			)
 			if !p {
@@ -332,8 +335,9 @@ func (p *patchTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 		// Update history
 		file, err := p.files.GetByPathAndSession(ctx, absPath, sessionID)
-		if err != nil && change.Type != diff.ActionAdd {
-			// If not adding a file, create history entry for existing file
+		if err != nil {
+			// Create the history entry with the content before the patch, which
+			// is empty for added files
 			_, err = p.files.Create(ctx, sessionID, absPath, oldContent)
 			if err != nil {
 				logging.Debug("Error creating file history", "error", err)
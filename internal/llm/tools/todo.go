@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/task"
+)
+
+type TodoItem struct {
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+type TodoParams struct {
+	Todos []TodoItem `json:"todos"`
+}
+
+type TodoResponseMetadata struct {
+	Todos []TodoItem `json:"todos"`
+}
+
+type todoTool struct {
+	tasks task.Service
+}
+
+const (
+	TodoToolName    = "todo"
+	todoDescription = `Task list tool that records the plan for the current session and tracks the progress of each step. The user sees this list next to the conversation.
+
+WHEN TO USE THIS TOOL:
+- Use when a request needs three or more distinct steps
+- Use when following a markdown checklist (- [ ] task) from an instruction file
+- Use to mark a step in_progress before starting it and completed right after finishing it
+
+HOW TO USE:
+- Always send the complete list of tasks, in execution order
+- Keep the "id" of tasks returned by previous calls; omit "id" for new tasks
+- Tasks left out of the list are removed
+- Status must be one of: pending, in_progress, completed, cancelled
+- Keep exactly one task in_progress while you are working
+
+VETOED TASKS:
+- The user can veto a task. Vetoed tasks are reported back by this tool
+- Never execute a vetoed task and never try to change its status
+- Vetoed tasks are kept even if you leave them out of the list
+
+TIPS:
+- Call this tool again before each step to see whether the user vetoed anything
+- Do not use this tool for a single trivial step`
+)
+
+func NewTodoTool(tasks task.Service) BaseTool {
+	return &todoTool{
+		tasks: tasks,
+	}
+}
+
+func (t *todoTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        TodoToolName,
+		Description: todoDescription,
+		Parameters: map[string]any{
+			"todos": map[string]any{
+				"type":        "array",
+				"description": "The complete, ordered task list",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"id": map[string]any{
+							"type":        "string",
+							"description": "The ID of an existing task (omit for new tasks)",
+						},
+						"content": map[string]any{
+							"type":        "string",
+							"description": "A short description of the step",
+						},
+						"status": map[string]any{
+							"type":        "string",
+							"description": "pending, in_progress, completed or cancelled",
+							"enum":        []string{"pending", "in_progress", "completed", "cancelled"},
+						},
+					},
+					"required": []string{"content", "status"},
+				},
+			},
+		},
+		Required: []string{"todos"},
+	}
+}
+
+func (t *todoTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params TodoParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	sessionID, _ := GetContextValues(ctx)
+	if sessionID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID is required for updating the task list")
+	}
+
+	for _, item := range params.Todos {
+		if strings.TrimSpace(item.Content) == "" {
+			return NewTextErrorResponse("content is required for every task"), nil
+		}
+		if !task.Status(item.Status).IsValid() {
+			return NewTextErrorResponse(fmt.Sprintf("invalid status %q for task %q", item.Status, item.Content)), nil
+		}
+	}
+
+	existing, err := t.tasks.List(ctx, sessionID)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error listing tasks: %w", err)
+	}
+	byID := make(map[string]task.Task, len(existing))
+	for _, e := range existing {
+		byID[e.ID] = e
+	}
+
+	var ignored []string
+	seen := make(map[string]bool, len(params.Todos))
+	for i, item := range params.Todos {
+		position := int64(i)
+		current, ok := byID[item.ID]
+		if !ok || seen[item.ID] {
+			if _, err := t.tasks.Create(ctx, sessionID, position, item.Content, task.Status(item.Status)); err != nil {
+				return ToolResponse{}, fmt.Errorf("error creating task: %w", err)
+			}
+			continue
+		}
+		seen[item.ID] = true
+
+		updated := current
+		updated.Position = position
+		updated.Content = item.Content
+		if current.Status == task.StatusVetoed {
+			if item.Status != string(task.StatusPending) && item.Status != string(task.StatusCancelled) {
+				ignored = append(ignored, current.Content)
+			}
+		} else {
+			updated.Status = task.Status(item.Status)
+		}
+		if updated == current {
+			continue
+		}
+		if _, err := t.tasks.Update(ctx, updated); err != nil {
+			return ToolResponse{}, fmt.Errorf("error updating task: %w", err)
+		}
+	}
+
+	// Tasks left out of the list are dropped, except the ones the user vetoed.
+	position := int64(len(params.Todos))
+	for _, e := range existing {
+		if seen[e.ID] {
+			continue
+		}
+		if e.Status == task.StatusVetoed {
+			e.Position = position
+			position++
+			if _, err := t.tasks.Update(ctx, e); err != nil {
+				return ToolResponse{}, fmt.Errorf("error updating task: %w", err)
+			}
+			continue
+		}
+		if err := t.tasks.Delete(ctx, e.ID); err != nil {
+			return ToolResponse{}, fmt.Errorf("error deleting task: %w", err)
+		}
+	}
+
+	tasks, err := t.tasks.List(ctx, sessionID)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error listing tasks: %w", err)
+	}
+	return WithResponseMetadata(
+		NewTextResponse(formatTodoList(tasks, ignored)),
+		TodoResponseMetadata{Todos: todoItems(tasks)},
+	), nil
+}
+
+func todoItems(tasks []task.Task) []TodoItem {
+	items := make([]TodoItem, len(tasks))
+	for i, t := range tasks {
+		items[i] = TodoItem{ID: t.ID, Content: t.Content, Status: string(t.Status)}
+	}
+	return items
+}
+
+func formatTodoList(tasks []task.Task, ignored []string) string {
+	if len(tasks) == 0 {
+		return "The task list is empty."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Current task list:\n")
+	var vetoed []string
+	for _, t := range tasks {
+		fmt.Fprintf(&sb, "- [%s] %s (id: %s)\n", t.Status, t.Content, t.ID)
+		if t.Status == task.StatusVetoed {
+			vetoed = append(vetoed, t.Content)
+		}
+	}
+	if len(vetoed) > 0 {
+		sb.WriteString("\nThe user vetoed the following tasks. Do NOT execute them:\n")
+		for _, v := range vetoed {
+			fmt.Fprintf(&sb, "- %s\n", v)
+		}
+	}
+	if len(ignored) > 0 {
+		sb.WriteString("\nStatus changes to vetoed tasks were ignored:\n")
+		for _, v := range ignored {
+			fmt.Fprintf(&sb, "- %s\n", v)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -179,6 +179,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 				FilePath: filePath,
 				Diff:     diff,
 			},
+			FilePath: filePath,
 		},
 	)
 	if !p {
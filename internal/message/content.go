@@ -26,6 +26,7 @@ const (
 	FinishReasonCanceled         FinishReason = "canceled"
 	FinishReasonError            FinishReason = "error"
 	FinishReasonPermissionDenied FinishReason = "permission_denied"
+	FinishReasonRejected         FinishReason = "rejected"
 
 	// Should never happen
 	FinishReasonUnknown FinishReason = "unknown"
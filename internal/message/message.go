@@ -25,6 +25,7 @@ type Service interface {
 	Update(ctx context.Context, message Message) error
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
+	Import(ctx context.Context, sessionID string, message Message) (Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
 }
@@ -82,6 +83,39 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 	return message, nil
 }
 
+// Import copies a message into the session under a new ID, keeping its parts
+// and timestamps so the original order is preserved.
+func (s *service) Import(ctx context.Context, sessionID string, message Message) (Message, error) {
+	partsJSON, err := marshallParts(message.Parts)
+	if err != nil {
+		return Message{}, err
+	}
+	finishedAt := sql.NullInt64{}
+	if f := message.FinishPart(); f != nil {
+		finishedAt.Int64 = f.Time
+		finishedAt.Valid = true
+	}
+	dbMessage, err := s.q.ImportMessage(ctx, db.ImportMessageParams{
+		ID:         uuid.New().String(),
+		SessionID:  sessionID,
+		Role:       string(message.Role),
+		Parts:      string(partsJSON),
+		Model:      sql.NullString{String: string(message.Model), Valid: message.Model != ""},
+		FinishedAt: finishedAt,
+		CreatedAt:  message.CreatedAt,
+		UpdatedAt:  message.UpdatedAt,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	imported, err := s.fromDBItem(dbMessage)
+	if err != nil {
+		return Message{}, err
+	}
+	s.Publish(pubsub.CreatedEvent, imported)
+	return imported, nil
+}
+
 func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) error {
 	messages, err := s.List(ctx, sessionID)
 	if err != nil {
@@ -111,6 +145,7 @@ func (s *service) Update(ctx context.Context, message Message) error {
 	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
 		ID:         message.ID,
 		Parts:      string(parts),
+		Model:      sql.NullString{String: string(message.Model), Valid: message.Model != ""},
 		FinishedAt: finishedAt,
 	})
 	if err != nil {
@@ -279,3 +314,56 @@ func unmarshallParts(data []byte) ([]ContentPart, error) {
 
 	return parts, nil
 }
+
+// messageJSON is the JSON form of a message used by the API server and
+// session export. Parts keep the same typed envelope as the database.
+type messageJSON struct {
+	ID        string          `json:"id"`
+	Role      MessageRole     `json:"role"`
+	SessionID string          `json:"session_id"`
+	Parts     json.RawMessage `json:"parts"`
+	Model     models.ModelID  `json:"model,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+	UpdatedAt int64           `json:"updated_at"`
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	parts, err := marshallParts(m.Parts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{
+		ID:        m.ID,
+		Role:      m.Role,
+		SessionID: m.SessionID,
+		Parts:     parts,
+		Model:     m.Model,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	})
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw messageJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parts := []ContentPart{}
+	if len(raw.Parts) > 0 && string(raw.Parts) != "null" {
+		var err error
+		parts, err = unmarshallParts(raw.Parts)
+		if err != nil {
+			return err
+		}
+	}
+	*m = Message{
+		ID:        raw.ID,
+		Role:      raw.Role,
+		SessionID: raw.SessionID,
+		Parts:     parts,
+		Model:     raw.Model,
+		CreatedAt: raw.CreatedAt,
+		UpdatedAt: raw.UpdatedAt,
+	}
+	return nil
+}
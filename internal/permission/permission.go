@@ -1,12 +1,15 @@
 package permission
 
 import (
+	"context"
 	"errors"
 	"path/filepath"
 	"slices"
 	"sync"
 
 	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/db"
+	"github.com/cap-ai/cap/internal/logging"
 	"github.com/cap-ai/cap/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -20,6 +23,10 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// FilePath and Command are the file and bash command the request is about.
+	// They are matched against the permission rules in the config.
+	FilePath string `json:"file_path,omitempty"`
+	Command  string `json:"command,omitempty"`
 }
 
 type PermissionRequest struct {
@@ -30,6 +37,23 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	FilePath    string `json:"file_path,omitempty"`
+	Command     string `json:"command,omitempty"`
+}
+
+type NotificationReason string
+
+const (
+	NotificationReasonRule        NotificationReason = "rule"
+	NotificationReasonAutoApprove NotificationReason = "auto_approve"
+	NotificationReasonSession     NotificationReason = "session"
+)
+
+// PermissionNotification reports a request that was decided without asking the user.
+type PermissionNotification struct {
+	PermissionRequest
+	Granted bool               `json:"granted"`
+	Reason  NotificationReason `json:"reason"`
 }
 
 type Service interface {
@@ -38,11 +62,15 @@ type Service interface {
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(opts CreatePermissionRequest) bool
+	Evaluate(opts CreatePermissionRequest) config.PermissionDecision
 	AutoApproveSession(sessionID string)
+	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
 }
 
 type permissionService struct {
 	*pubsub.Broker[PermissionRequest]
+	q             db.Querier
+	notifications *pubsub.Broker[PermissionNotification]
 
 	sessionPermissions  []PermissionRequest
 	pendingRequests     sync.Map
@@ -55,6 +83,16 @@ func (s *permissionService) GrantPersistant(permission PermissionRequest) {
 		respCh.(chan bool) <- true
 	}
 	s.sessionPermissions = append(s.sessionPermissions, permission)
+	_, err := s.q.CreatePermissionGrant(context.Background(), db.CreatePermissionGrantParams{
+		ID:        uuid.New().String(),
+		SessionID: permission.SessionID,
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Path:      permission.Path,
+	})
+	if err != nil {
+		logging.Error("failed to persist permission grant", "error", err)
+	}
 }
 
 func (s *permissionService) Grant(permission PermissionRequest) {
@@ -72,9 +110,6 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
-	if slices.Contains(s.autoApproveSessions, opts.SessionID) {
-		return true
-	}
 	dir := filepath.Dir(opts.Path)
 	if dir == "." {
 		dir = config.WorkingDirectory()
@@ -87,12 +122,29 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		Description: opts.Description,
 		Action:      opts.Action,
 		Params:      opts.Params,
+		FilePath:    opts.FilePath,
+		Command:     opts.Command,
 	}
 
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			return true
-		}
+	decision := s.Evaluate(opts)
+	switch decision {
+	case config.PermissionDeny:
+		logging.Info("permission denied by rule", "tool", opts.ToolName, "action", opts.Action, "file", opts.FilePath, "command", opts.Command)
+		s.notify(permission, false, NotificationReasonRule)
+		return false
+	case config.PermissionAllow:
+		s.notify(permission, true, NotificationReasonRule)
+		return true
+	}
+	if slices.Contains(s.autoApproveSessions, opts.SessionID) {
+		s.notify(permission, true, NotificationReasonAutoApprove)
+		return true
+	}
+
+	// An "ask" rule always prompts, even if the user allowed it for the session.
+	if decision != config.PermissionAsk && s.isGranted(permission) {
+		s.notify(permission, true, NotificationReasonSession)
+		return true
 	}
 
 	respCh := make(chan bool, 1)
@@ -107,13 +159,48 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	return resp
 }
 
+// isGranted reports whether the user already allowed the request for the session,
+// either in this process or before a restart.
+func (s *permissionService) isGranted(permission PermissionRequest) bool {
+	for _, p := range s.sessionPermissions {
+		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
+			return true
+		}
+	}
+	_, err := s.q.FindPermissionGrant(context.Background(), db.FindPermissionGrantParams{
+		SessionID: permission.SessionID,
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Path:      permission.Path,
+	})
+	if err != nil {
+		return false
+	}
+	s.sessionPermissions = append(s.sessionPermissions, permission)
+	return true
+}
+
+func (s *permissionService) notify(permission PermissionRequest, granted bool, reason NotificationReason) {
+	s.notifications.Publish(pubsub.CreatedEvent, PermissionNotification{
+		PermissionRequest: permission,
+		Granted:           granted,
+		Reason:            reason,
+	})
+}
+
+func (s *permissionService) SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification] {
+	return s.notifications.Subscribe(ctx)
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessions = append(s.autoApproveSessions, sessionID)
 }
 
-func NewPermissionService() Service {
+func NewPermissionService(q db.Querier) Service {
 	return &permissionService{
 		Broker:             pubsub.NewBroker[PermissionRequest](),
+		q:                  q,
+		notifications:      pubsub.NewBroker[PermissionNotification](),
 		sessionPermissions: make([]PermissionRequest, 0),
 	}
 }
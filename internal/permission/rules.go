@@ -0,0 +1,154 @@
+package permission
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/cap-ai/cap/internal/config"
+)
+
+// Evaluate returns the decision of the permission rules for the request, or an
+// empty decision when no rule matches. When several rules match, deny wins over
+// ask and ask wins over allow.
+func (s *permissionService) Evaluate(opts CreatePermissionRequest) config.PermissionDecision {
+	cfg := config.Get()
+	if cfg == nil {
+		return ""
+	}
+	return evaluateRules(cfg.Permissions.Rules, opts, cfg.WorkingDir)
+}
+
+func evaluateRules(rules []config.PermissionRule, opts CreatePermissionRequest, workingDir string) config.PermissionDecision {
+	var result config.PermissionDecision
+	for _, rule := range rules {
+		if !matchRule(rule, opts, workingDir) {
+			continue
+		}
+		switch rule.Decision {
+		case config.PermissionDeny:
+			return config.PermissionDeny
+		case config.PermissionAsk:
+			result = config.PermissionAsk
+		case config.PermissionAllow:
+			if result == "" {
+				result = config.PermissionAllow
+			}
+		}
+	}
+	return result
+}
+
+func matchRule(rule config.PermissionRule, opts CreatePermissionRequest, workingDir string) bool {
+	if rule.Tool != "" && rule.Tool != opts.ToolName {
+		return false
+	}
+	if rule.Action != "" && rule.Action != opts.Action {
+		return false
+	}
+	if rule.Command != "" {
+		// An allow rule must cover every command in a chain such as
+		// "go test ./... && rm -rf /", while deny and ask rules match any of them.
+		if !matchCommand(rule.Command, opts.Command, rule.Decision == config.PermissionAllow) {
+			return false
+		}
+	}
+	if rule.Path != "" {
+		matched := false
+		for _, p := range requestPaths(opts) {
+			if matchPath(rule.Path, p, workingDir) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCommand matches a command pattern where * matches any characters.
+func matchCommand(pattern, command string, all bool) bool {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return false
+	}
+	// Substitutions can run anything, so they are never allowed by a pattern.
+	if all && (strings.Contains(command, "`") || strings.Contains(command, "$(")) {
+		return false
+	}
+	parts := strings.Split(strings.TrimSpace(pattern), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re := regexp.MustCompile(`^` + strings.Join(parts, `.*`) + `$`)
+
+	segments := splitCommand(command)
+	for _, segment := range segments {
+		matched := re.MatchString(segment)
+		if all && !matched {
+			return false
+		}
+		if !all && matched {
+			return true
+		}
+	}
+	return all && len(segments) > 0
+}
+
+// splitCommand splits a shell command on &&, ||, ;, | and newlines.
+func splitCommand(command string) []string {
+	replacer := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n")
+	var segments []string
+	for _, segment := range strings.Split(replacer.Replace(command), "\n") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// requestPaths returns the paths a request touches. For bash commands every
+// argument is treated as a possible path.
+func requestPaths(opts CreatePermissionRequest) []string {
+	if opts.FilePath != "" {
+		return []string{opts.FilePath}
+	}
+	if opts.Command != "" {
+		return strings.FieldsFunc(opts.Command, func(r rune) bool {
+			return strings.ContainsRune(" \t\n;|&<>=()'\"`", r)
+		})
+	}
+	return nil
+}
+
+// matchPath matches a path against a glob relative to the working directory.
+// A pattern ending in / matches everything below the directory and a pattern
+// without / matches the file name in any directory, like .gitignore.
+func matchPath(pattern, p, workingDir string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(workingDir, p)
+	}
+	candidate := filepath.ToSlash(filepath.Clean(p))
+	if !filepath.IsAbs(pattern) {
+		rel, err := filepath.Rel(workingDir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return false
+		}
+		candidate = filepath.ToSlash(rel)
+	}
+	if ok, _ := doublestar.Match(pattern, candidate); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := doublestar.Match(pattern, path.Base(candidate))
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	wd := "/work/project"
+	rules := []config.PermissionRule{
+		{Tool: "bash", Command: "go test *", Decision: config.PermissionAllow},
+		{Tool: "edit", Path: "internal/**", Decision: config.PermissionAllow},
+		{Path: "**/.env", Decision: config.PermissionDeny},
+		{Path: "deploy/", Decision: config.PermissionDeny},
+		{Tool: "bash", Command: "git push*", Decision: config.PermissionAsk},
+	}
+
+	tests := []struct {
+		name string
+		opts CreatePermissionRequest
+		want config.PermissionDecision
+	}{
+		{
+			name: "allowed command",
+			opts: CreatePermissionRequest{ToolName: "bash", Command: "go test ./..."},
+			want: config.PermissionAllow,
+		},
+		{
+			name: "allowed command chained with another command",
+			opts: CreatePermissionRequest{ToolName: "bash", Command: "go test ./... && rm -rf /tmp/x"},
+			want: "",
+		},
+		{
+			name: "allowed edit",
+			opts: CreatePermissionRequest{ToolName: "edit", FilePath: "/work/project/internal/app/app.go"},
+			want: config.PermissionAllow,
+		},
+		{
+			name: "edit outside allowed directory",
+			opts: CreatePermissionRequest{ToolName: "edit", FilePath: "/work/project/cmd/root.go"},
+			want: "",
+		},
+		{
+			name: "deny wins over allow",
+			opts: CreatePermissionRequest{ToolName: "edit", FilePath: "/work/project/internal/.env"},
+			want: config.PermissionDeny,
+		},
+		{
+			name: "env file in project root",
+			opts: CreatePermissionRequest{ToolName: "write", FilePath: "/work/project/.env"},
+			want: config.PermissionDeny,
+		},
+		{
+			name: "command touching denied path",
+			opts: CreatePermissionRequest{ToolName: "bash", Command: "cat .env"},
+			want: config.PermissionDeny,
+		},
+		{
+			name: "command touching denied directory",
+			opts: CreatePermissionRequest{ToolName: "bash", Command: "rm -rf deploy"},
+			want: config.PermissionDeny,
+		},
+		{
+			name: "patch under denied directory",
+			opts: CreatePermissionRequest{ToolName: "patch", FilePath: "deploy/k8s/app.yaml"},
+			want: config.PermissionDeny,
+		},
+		{
+			name: "ask rule",
+			opts: CreatePermissionRequest{ToolName: "bash", Command: "git push origin main"},
+			want: config.PermissionAsk,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evaluateRules(rules, tt.opts, wd))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cap-ai/cap/internal/llm/agent"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/permission"
+	"github.com/cap-ai/cap/internal/pubsub"
+	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
+)
+
+// streamEvent is a single Server-Sent Event.
+type streamEvent struct {
+	Name      string
+	SessionID string
+	Data      any
+}
+
+// eventData is the payload of every streamed event.
+type eventData struct {
+	Type    pubsub.EventType `json:"type"`
+	Payload any              `json:"payload"`
+}
+
+// agentEventPayload is the JSON form of agent.AgentEvent, whose error field
+// does not marshal on its own.
+type agentEventPayload struct {
+	Type      agent.AgentEventType `json:"type"`
+	SessionID string               `json:"session_id,omitempty"`
+	Message   *message.Message     `json:"message,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Progress  string               `json:"progress,omitempty"`
+	Done      bool                 `json:"done,omitempty"`
+	ToolCalls []message.ToolCall   `json:"tool_calls,omitempty"`
+}
+
+func newAgentEventPayload(e agent.AgentEvent) agentEventPayload {
+	payload := agentEventPayload{
+		Type:      e.Type,
+		SessionID: e.SessionID,
+		Progress:  e.Progress,
+		Done:      e.Done,
+		ToolCalls: e.ToolCalls,
+	}
+	if e.Message.ID != "" {
+		msg := e.Message
+		payload.Message = &msg
+		if payload.SessionID == "" {
+			payload.SessionID = msg.SessionID
+		}
+	}
+	if e.Error != nil {
+		payload.Error = e.Error.Error()
+	}
+	return payload
+}
+
+// events streams the service events. The session_id query parameter limits
+// the stream to a single session.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	sessionID := r.URL.Query().Get("session_id")
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	out := make(chan streamEvent, 100)
+	forward(ctx, out, "session", s.app.Sessions, func(e session.Session) (string, any) {
+		return e.ID, e
+	})
+	forward(ctx, out, "message", s.app.Messages, func(e message.Message) (string, any) {
+		return e.SessionID, e
+	})
+	forward(ctx, out, "permission", s.app.Permissions, func(e permission.PermissionRequest) (string, any) {
+		return e.SessionID, e
+	})
+	forward(ctx, out, "task", s.app.Tasks, func(e task.Task) (string, any) {
+		return e.SessionID, e
+	})
+	forward(ctx, out, "agent", s.app.CoderAgent, func(e agent.AgentEvent) (string, any) {
+		payload := newAgentEventPayload(e)
+		return payload.SessionID, payload
+	})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-out:
+			if sessionID != "" && event.SessionID != sessionID {
+				continue
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				logging.Error("failed to marshal event", "event", event.Name, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// forward subscribes to a service and sends its events to out until ctx is done.
+func forward[T any](
+	ctx context.Context,
+	out chan<- streamEvent,
+	name string,
+	subscriber pubsub.Suscriber[T],
+	convert func(T) (string, any),
+) {
+	subCh := subscriber.Subscribe(ctx)
+	go func() {
+		defer logging.RecoverPanic(fmt.Sprintf("server-events-%s", name), nil)
+		for event := range subCh {
+			sessionID, payload := convert(event.Payload)
+			select {
+			case out <- streamEvent{
+				Name:      name,
+				SessionID: sessionID,
+				Data:      eventData{Type: event.Type, Payload: payload},
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
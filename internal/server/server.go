@@ -0,0 +1,369 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cap-ai/cap/internal/app"
+	"github.com/cap-ai/cap/internal/llm/agent"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/permission"
+	"github.com/cap-ai/cap/internal/pubsub"
+)
+
+// Server exposes the app services over a local HTTP API. Events published by
+// the services are streamed to clients with Server-Sent Events.
+type Server struct {
+	app   *app.App
+	token string
+
+	// ctx outlives single requests, so agent runs keep going after the HTTP
+	// request that started them returns.
+	ctx context.Context
+
+	// pendingPermissions holds permission requests waiting for an answer.
+	pendingPermissions sync.Map
+}
+
+// New creates a server for the app. When token is not empty every request must
+// carry it as a bearer token.
+func New(ctx context.Context, app *app.App, token string) *Server {
+	s := &Server{
+		app:   app,
+		token: token,
+		ctx:   ctx,
+	}
+	go s.trackPermissions()
+	return s
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions", s.listSessions)
+	mux.HandleFunc("POST /sessions", s.createSession)
+	mux.HandleFunc("GET /sessions/{id}", s.getSession)
+	mux.HandleFunc("DELETE /sessions/{id}", s.deleteSession)
+	mux.HandleFunc("GET /sessions/{id}/messages", s.listMessages)
+	mux.HandleFunc("GET /sessions/{id}/tasks", s.listTasks)
+	mux.HandleFunc("POST /sessions/{id}/run", s.run)
+	mux.HandleFunc("POST /sessions/{id}/cancel", s.cancel)
+	mux.HandleFunc("POST /sessions/{id}/summarize", s.summarize)
+	mux.HandleFunc("POST /sessions/{id}/leash", s.setLeash)
+	mux.HandleFunc("POST /sessions/{id}/step", s.respondStep)
+	mux.HandleFunc("GET /permissions", s.listPermissions)
+	mux.HandleFunc("POST /permissions/{id}", s.respondPermission)
+	mux.HandleFunc("GET /events", s.events)
+	return s.authorize(mux)
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	logging.Info("API server listening", "addr", addr)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.app.Sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+type createSessionRequest struct {
+	Title string `json:"title"`
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Title == "" {
+		req.Title = "New Session"
+	}
+	sess, err := s.app.Sessions.Create(r.Context(), req.Title)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.app.Sessions.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.app.CoderAgent.IsSessionBusy(id) {
+		writeError(w, http.StatusConflict, agent.ErrSessionBusy)
+		return
+	}
+	if err := s.app.Sessions.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	messages, err := s.app.Messages.List(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, messages)
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.app.Tasks.List(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+type runRequest struct {
+	Prompt string `json:"prompt"`
+	// Wait blocks the request until the run finishes and returns the final message.
+	Wait bool `json:"wait"`
+}
+
+type runResponse struct {
+	SessionID string           `json:"session_id"`
+	Message   *message.Message `json:"message,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+func (s *Server) run(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req runRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Prompt == "" {
+		writeError(w, http.StatusBadRequest, errors.New("prompt is required"))
+		return
+	}
+	if _, err := s.app.Sessions.Get(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	done, err := s.app.CoderAgent.Run(s.ctx, id, req.Prompt)
+	if err != nil {
+		if errors.Is(err, agent.ErrSessionBusy) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !req.Wait {
+		// The agent blocks until its result is read, so drain it in the background.
+		go func() {
+			<-done
+		}()
+		writeJSON(w, http.StatusAccepted, runResponse{SessionID: id})
+		return
+	}
+
+	select {
+	case result := <-done:
+		resp := runResponse{SessionID: id}
+		if result.Error != nil {
+			resp.Error = result.Error.Error()
+		} else {
+			resp.Message = &result.Message
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case <-r.Context().Done():
+		s.app.CoderAgent.Cancel(id)
+		<-done
+	}
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	s.app.CoderAgent.Cancel(r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) summarize(w http.ResponseWriter, r *http.Request) {
+	if err := s.app.CoderAgent.Summarize(s.ctx, r.PathValue("id")); err != nil {
+		if errors.Is(err, agent.ErrSessionBusy) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type leashRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (s *Server) setLeash(w http.ResponseWriter, r *http.Request) {
+	var req leashRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	s.app.CoderAgent.SetLeash(r.PathValue("id"), req.Enabled)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type stepRequest struct {
+	Action    agent.StepAction   `json:"action"`
+	ToolCalls []message.ToolCall `json:"tool_calls,omitempty"`
+	Feedback  string             `json:"feedback,omitempty"`
+}
+
+func (s *Server) respondStep(w http.ResponseWriter, r *http.Request) {
+	var req stepRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	switch req.Action {
+	case agent.StepApprove, agent.StepEdit, agent.StepReject:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown step action %q", req.Action))
+		return
+	}
+	s.app.CoderAgent.RespondStep(r.PathValue("id"), agent.StepDecision{
+		Action:    req.Action,
+		ToolCalls: req.ToolCalls,
+		Feedback:  req.Feedback,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trackPermissions remembers permission requests until a client answers them.
+func (s *Server) trackPermissions() {
+	defer logging.RecoverPanic("server-permissions", nil)
+	for event := range s.app.Permissions.Subscribe(s.ctx) {
+		if event.Type == pubsub.CreatedEvent {
+			s.pendingPermissions.Store(event.Payload.ID, event.Payload)
+		}
+	}
+}
+
+func (s *Server) listPermissions(w http.ResponseWriter, r *http.Request) {
+	pending := []permission.PermissionRequest{}
+	s.pendingPermissions.Range(func(_, value any) bool {
+		pending = append(pending, value.(permission.PermissionRequest))
+		return true
+	})
+	writeJSON(w, http.StatusOK, pending)
+}
+
+type permissionResponse struct {
+	// Action is one of allow, allow_session or deny.
+	Action string `json:"action"`
+}
+
+func (s *Server) respondPermission(w http.ResponseWriter, r *http.Request) {
+	var req permissionResponse
+	if !readJSON(w, r, &req) {
+		return
+	}
+	value, ok := s.pendingPermissions.Load(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("permission request not found"))
+		return
+	}
+	p := value.(permission.PermissionRequest)
+	switch req.Action {
+	case "allow":
+		s.app.Permissions.Grant(p)
+	case "allow_session":
+		s.app.Permissions.GrantPersistant(p)
+	case "deny":
+		s.app.Permissions.Deny(p)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown permission action %q", req.Action))
+		return
+	}
+	s.pendingPermissions.Delete(p.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error("failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
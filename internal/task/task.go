@@ -0,0 +1,172 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cap-ai/cap/internal/db"
+	"github.com/cap-ai/cap/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusCancelled  Status = "cancelled"
+	// StatusVetoed is set by the user only. The agent must skip vetoed tasks
+	// and cannot change their status.
+	StatusVetoed Status = "vetoed"
+)
+
+// IsValid reports whether the status can be set by the agent.
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusInProgress, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+type Task struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	Position  int64  `json:"position"`
+	Content   string `json:"content"`
+	Status    Status `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type Service interface {
+	pubsub.Suscriber[Task]
+	Create(ctx context.Context, sessionID string, position int64, content string, status Status) (Task, error)
+	Get(ctx context.Context, id string) (Task, error)
+	List(ctx context.Context, sessionID string) ([]Task, error)
+	Update(ctx context.Context, task Task) (Task, error)
+	ToggleVeto(ctx context.Context, id string) (Task, error)
+	Delete(ctx context.Context, id string) error
+	DeleteSessionTasks(ctx context.Context, sessionID string) error
+}
+
+type service struct {
+	*pubsub.Broker[Task]
+	q db.Querier
+}
+
+func NewService(q db.Querier) Service {
+	return &service{
+		Broker: pubsub.NewBroker[Task](),
+		q:      q,
+	}
+}
+
+func (s *service) Create(ctx context.Context, sessionID string, position int64, content string, status Status) (Task, error) {
+	dbTask, err := s.q.CreateTask(ctx, db.CreateTaskParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Position:  position,
+		Content:   content,
+		Status:    string(status),
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	task := s.fromDBItem(dbTask)
+	s.Publish(pubsub.CreatedEvent, task)
+	return task, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (Task, error) {
+	dbTask, err := s.q.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	return s.fromDBItem(dbTask), nil
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Task, error) {
+	dbTasks, err := s.q.ListTasksBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]Task, len(dbTasks))
+	for i, dbTask := range dbTasks {
+		tasks[i] = s.fromDBItem(dbTask)
+	}
+	return tasks, nil
+}
+
+func (s *service) Update(ctx context.Context, task Task) (Task, error) {
+	dbTask, err := s.q.UpdateTask(ctx, db.UpdateTaskParams{
+		ID:       task.ID,
+		Position: task.Position,
+		Content:  task.Content,
+		Status:   string(task.Status),
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	task = s.fromDBItem(dbTask)
+	s.Publish(pubsub.UpdatedEvent, task)
+	return task, nil
+}
+
+// ToggleVeto vetoes a task so the agent skips it, or puts a vetoed task back
+// to pending. Finished tasks cannot be vetoed.
+func (s *service) ToggleVeto(ctx context.Context, id string) (Task, error) {
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	switch task.Status {
+	case StatusVetoed:
+		task.Status = StatusPending
+	case StatusCompleted, StatusCancelled:
+		return Task{}, fmt.Errorf("task is already %s", task.Status)
+	default:
+		task.Status = StatusVetoed
+	}
+	return s.Update(ctx, task)
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.q.DeleteTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.DeletedEvent, task)
+	return nil
+}
+
+func (s *service) DeleteSessionTasks(ctx context.Context, sessionID string) error {
+	tasks, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		err = s.Delete(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) fromDBItem(item db.Task) Task {
+	return Task{
+		ID:        item.ID,
+		SessionID: item.SessionID,
+		Position:  item.Position,
+		Content:   item.Content,
+		Status:    Status(item.Status),
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
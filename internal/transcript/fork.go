@@ -0,0 +1,64 @@
+package transcript
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/session"
+)
+
+// Fork copies the session up to and including the given message into a new
+// session, leaving the original untouched. File versions recorded after that
+// point are not copied.
+func Fork(ctx context.Context, svc Services, sessionID, messageID string) (session.Session, error) {
+	bundle, err := Export(ctx, svc, sessionID)
+	if err != nil {
+		return session.Session{}, err
+	}
+	bundle, err = forkBundle(bundle, messageID)
+	if err != nil {
+		return session.Session{}, err
+	}
+	return Import(ctx, svc, bundle)
+}
+
+// forkBundle cuts the bundle after the given message. When the message calls
+// tools, the tool results that follow it are kept so that no call is left
+// without a response.
+func forkBundle(bundle Bundle, messageID string) (Bundle, error) {
+	end := -1
+	for i, msg := range bundle.Messages {
+		if msg.ID == messageID {
+			end = i + 1
+			break
+		}
+	}
+	if end < 0 {
+		return Bundle{}, fmt.Errorf("message %s not found in session %s", messageID, bundle.Session.ID)
+	}
+	if len(bundle.Messages[end-1].ToolCalls()) > 0 {
+		for end < len(bundle.Messages) && bundle.Messages[end].Role == message.Tool {
+			end++
+		}
+	}
+	msgs := bundle.Messages[:end]
+
+	cutoff := msgs[len(msgs)-1].UpdatedAt
+	var files []history.File
+	for _, file := range bundle.Files {
+		if file.CreatedAt <= cutoff {
+			files = append(files, file)
+		}
+	}
+
+	forked := bundle
+	forked.Session.Title = bundle.Session.Title + " (fork)"
+	forked.Session.PromptTokens = 0
+	forked.Session.CompletionTokens = 0
+	forked.Session.Cost = 0
+	forked.Messages = msgs
+	forked.Files = files
+	return forked, nil
+}
@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"testing"
+
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/session"
+)
+
+func TestForkBundle(t *testing.T) {
+	bundle := Bundle{
+		Version: BundleVersion,
+		Session: session.Session{ID: "s1", Title: "Refactor", PromptTokens: 10, Cost: 1},
+		Messages: []message.Message{
+			{ID: "u1", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "edit"}}, UpdatedAt: 1},
+			{ID: "a1", Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "c1", Name: "edit"}}, UpdatedAt: 2},
+			{ID: "t1", Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "c1"}}, UpdatedAt: 3},
+			{ID: "a2", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "done"}}, UpdatedAt: 4},
+			{ID: "u2", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "again"}}, UpdatedAt: 5},
+		},
+		Files: []history.File{
+			{Path: "a.go", Version: history.InitialVersion, CreatedAt: 1},
+			{Path: "a.go", Version: "v1", CreatedAt: 3},
+			{Path: "a.go", Version: "v2", CreatedAt: 6},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		messageID string
+		messages  []string
+		files     int
+	}{
+		{name: "keeps tool results of the forked message", messageID: "a1", messages: []string{"u1", "a1", "t1"}, files: 2},
+		{name: "first message", messageID: "u1", messages: []string{"u1"}, files: 1},
+		{name: "last message", messageID: "u2", messages: []string{"u1", "a1", "t1", "a2", "u2"}, files: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forked, err := forkBundle(bundle, tt.messageID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, msg := range forked.Messages {
+				ids = append(ids, msg.ID)
+			}
+			if len(ids) != len(tt.messages) {
+				t.Fatalf("messages = %v, want %v", ids, tt.messages)
+			}
+			for i := range ids {
+				if ids[i] != tt.messages[i] {
+					t.Fatalf("messages = %v, want %v", ids, tt.messages)
+				}
+			}
+			if len(forked.Files) != tt.files {
+				t.Errorf("files = %d, want %d", len(forked.Files), tt.files)
+			}
+			if forked.Session.PromptTokens != 0 || forked.Session.Cost != 0 {
+				t.Errorf("usage was copied: %+v", forked.Session)
+			}
+		})
+	}
+
+	if _, err := forkBundle(bundle, "missing"); err == nil {
+		t.Error("expected an error for an unknown message")
+	}
+	if len(bundle.Messages) != 5 {
+		t.Error("the original bundle was modified")
+	}
+}
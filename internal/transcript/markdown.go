@@ -0,0 +1,130 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cap-ai/cap/internal/diff"
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/message"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// WriteMarkdown writes a human readable transcript of the bundle, suitable for
+// attaching to a code review.
+func WriteMarkdown(w io.Writer, bundle Bundle) error {
+	bw := bufio.NewWriter(w)
+	sess := bundle.Session
+
+	fmt.Fprintf(bw, "# %s\n\n", sess.Title)
+	fmt.Fprintf(bw, "- Session: `%s`\n", sess.ID)
+	fmt.Fprintf(bw, "- Created: %s\n", formatTime(sess.CreatedAt))
+	fmt.Fprintf(bw, "- Messages: %d\n", len(bundle.Messages))
+	fmt.Fprintf(bw, "- Tokens: %d prompt / %d completion\n", sess.PromptTokens, sess.CompletionTokens)
+	fmt.Fprintf(bw, "- Cost: $%.4f\n\n", sess.Cost)
+
+	toolNames := make(map[string]string)
+	for _, msg := range bundle.Messages {
+		for _, call := range msg.ToolCalls() {
+			toolNames[call.ID] = call.Name
+		}
+	}
+
+	for _, msg := range bundle.Messages {
+		writeMessage(bw, msg, toolNames)
+	}
+
+	writeFiles(bw, bundle.Files)
+	return bw.Flush()
+}
+
+func writeMessage(w io.Writer, msg message.Message, toolNames map[string]string) {
+	switch msg.Role {
+	case message.User:
+		fmt.Fprintf(w, "## User (%s)\n\n", formatTime(msg.CreatedAt))
+	case message.Assistant:
+		fmt.Fprintf(w, "## Assistant (%s, %s)\n\n", msg.Model, formatTime(msg.CreatedAt))
+	case message.Tool:
+		// Tool results are written under their own headings below.
+	default:
+		fmt.Fprintf(w, "## %s (%s)\n\n", msg.Role, formatTime(msg.CreatedAt))
+	}
+
+	if reasoning := msg.ReasoningContent().Thinking; reasoning != "" {
+		fmt.Fprintf(w, "<details>\n<summary>Reasoning</summary>\n\n%s\n\n</details>\n\n", strings.TrimSpace(reasoning))
+	}
+	if text := msg.Content().Text; text != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(text))
+	}
+	for _, attachment := range msg.BinaryContent() {
+		fmt.Fprintf(w, "_Attachment: %s (%s)_\n\n", attachment.Path, attachment.MIMEType)
+	}
+	for _, call := range msg.ToolCalls() {
+		fmt.Fprintf(w, "### Tool call: %s\n\n", call.Name)
+		writeFence(w, "json", call.Input)
+	}
+	for _, result := range msg.ToolResults() {
+		name := result.Name
+		if name == "" {
+			name = toolNames[result.ToolCallID]
+		}
+		heading := "Tool result"
+		if result.IsError {
+			heading = "Tool error"
+		}
+		fmt.Fprintf(w, "### %s: %s\n\n", heading, name)
+		writeFence(w, "", result.Content)
+	}
+	if msg.Role == message.Assistant {
+		switch reason := msg.FinishReason(); reason {
+		case "", message.FinishReasonEndTurn, message.FinishReasonToolUse:
+		default:
+			fmt.Fprintf(w, "_Finished: %s_\n\n", reason)
+		}
+	}
+}
+
+// writeFiles writes the change made to each file, from its first to its last
+// recorded version.
+func writeFiles(w io.Writer, files []history.File) {
+	if len(files) == 0 {
+		return
+	}
+	var paths []string
+	first := make(map[string]history.File)
+	last := make(map[string]history.File)
+	for _, file := range files {
+		if _, ok := first[file.Path]; !ok {
+			paths = append(paths, file.Path)
+			first[file.Path] = file
+		}
+		last[file.Path] = file
+	}
+
+	fmt.Fprint(w, "## Files\n\n")
+	for _, path := range paths {
+		before, after := first[path], last[path]
+		patch, additions, removals := diff.GenerateDiff(before.Content, after.Content, path)
+		fmt.Fprintf(w, "### %s (%s → %s, +%d -%d)\n\n", path, before.Version, after.Version, additions, removals)
+		writeFence(w, "diff", patch)
+	}
+}
+
+func writeFence(w io.Writer, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(w, "%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(content, "\n"), fence)
+}
+
+func formatTime(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(ts, 0).Format(timeLayout)
+}
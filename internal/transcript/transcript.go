@@ -0,0 +1,134 @@
+// Package transcript exports sessions out of the database and imports them back.
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/logging"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/session"
+)
+
+// BundleVersion is the version of the JSON bundle format.
+const BundleVersion = 1
+
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatJSON     Format = "json"
+)
+
+// Bundle is a self-contained copy of a session that can be imported into
+// another database.
+type Bundle struct {
+	Version    int               `json:"version"`
+	ExportedAt int64             `json:"exported_at"`
+	Session    session.Session   `json:"session"`
+	Messages   []message.Message `json:"messages"`
+	Files      []history.File    `json:"files"`
+}
+
+// Services are the services a transcript is read from and written to.
+type Services struct {
+	Sessions session.Service
+	Messages message.Service
+	History  history.Service
+}
+
+// Export reads the session with its messages and file versions.
+func Export(ctx context.Context, svc Services, sessionID string) (Bundle, error) {
+	sess, err := svc.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := svc.Messages.List(ctx, sessionID)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	files, err := svc.History.ListBySession(ctx, sessionID)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("failed to list files: %w", err)
+	}
+	return Bundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now().Unix(),
+		Session:    sess,
+		Messages:   msgs,
+		Files:      files,
+	}, nil
+}
+
+// Write writes the bundle in the given format.
+func Write(w io.Writer, bundle Bundle, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bundle)
+	case FormatMarkdown:
+		return WriteMarkdown(w, bundle)
+	default:
+		return fmt.Errorf("unknown format %q (use md or json)", format)
+	}
+}
+
+// Read reads a JSON bundle.
+func Read(r io.Reader) (Bundle, error) {
+	var bundle Bundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return Bundle{}, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	if bundle.Version == 0 || bundle.Version > BundleVersion {
+		return Bundle{}, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+// Import creates a new session from the bundle. Messages and file versions get
+// new IDs and keep their timestamps. Nothing is left behind when it fails.
+func Import(ctx context.Context, svc Services, bundle Bundle) (session.Session, error) {
+	sess, err := svc.Sessions.Create(ctx, bundle.Session.Title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	sess, err = importInto(ctx, svc, sess, bundle)
+	if err != nil {
+		if delErr := svc.Sessions.Delete(ctx, sess.ID); delErr != nil {
+			logging.Error("failed to remove partially imported session", "session_id", sess.ID, "error", delErr)
+		}
+		return session.Session{}, err
+	}
+	return sess, nil
+}
+
+func importInto(ctx context.Context, svc Services, sess session.Session, bundle Bundle) (session.Session, error) {
+	messageIDs := make(map[string]string, len(bundle.Messages))
+	for _, msg := range bundle.Messages {
+		imported, err := svc.Messages.Import(ctx, sess.ID, msg)
+		if err != nil {
+			return sess, fmt.Errorf("failed to import message %s: %w", msg.ID, err)
+		}
+		messageIDs[msg.ID] = imported.ID
+	}
+	for _, file := range bundle.Files {
+		if _, err := svc.History.Import(ctx, sess.ID, file); err != nil {
+			return sess, fmt.Errorf("failed to import file %s: %w", file.Path, err)
+		}
+	}
+
+	sess.PromptTokens = bundle.Session.PromptTokens
+	sess.CompletionTokens = bundle.Session.CompletionTokens
+	sess.Cost = bundle.Session.Cost
+	sess.SummaryMessageID = messageIDs[bundle.Session.SummaryMessageID]
+	saved, err := svc.Sessions.Save(ctx, sess)
+	if err != nil {
+		return sess, fmt.Errorf("failed to save session: %w", err)
+	}
+	return saved, nil
+}
@@ -23,6 +23,13 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// RewindFilesMsg asks to rewind the files of the session to their state
+// before the given message.
+type RewindFilesMsg struct {
+	SessionID string
+	Message   message.Message
+}
+
 type EditorFocusMsg bool
 
 func header(width int) string {
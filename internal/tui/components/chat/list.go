@@ -9,6 +9,7 @@ import (
 	"github.com/cap-ai/cap/internal/message"
 	"github.com/cap-ai/cap/internal/pubsub"
 	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/transcript"
 	"github.com/cap-ai/cap/internal/tui/components/dialog"
 	"github.com/cap-ai/cap/internal/tui/styles"
 	"github.com/cap-ai/cap/internal/tui/theme"
@@ -32,6 +33,7 @@ type messagesCmp struct {
 	messages      []message.Message
 	uiMessages    []uiMessage
 	currentMsgID  string
+	selectedMsgID string
 	cachedContent map[string]cacheItem
 	spinner       spinner.Model
 	rendering     bool
@@ -42,8 +44,12 @@ type renderFinishedMsg struct{}
 type MessageKeys struct {
 	// PageDown     key.Binding
 	// PageUp       key.Binding
-	HalfPageUp   key.Binding
-	HalfPageDown key.Binding
+	HalfPageUp      key.Binding
+	HalfPageDown    key.Binding
+	SelectPrevious  key.Binding
+	SelectNext      key.Binding
+	ForkFromMessage key.Binding
+	RewindFiles     key.Binding
 }
 
 var messageKeys = MessageKeys{
@@ -63,6 +69,22 @@ var messageKeys = MessageKeys{
 		key.WithKeys("ctrl+d", "ctrl+d"),
 		key.WithHelp("ctrl+d", "⬇︎ ページを下へ"),
 	),
+	SelectPrevious: key.NewBinding(
+		key.WithKeys("alt+up"),
+		key.WithHelp("alt+↑", "前のメッセージを選択"),
+	),
+	SelectNext: key.NewBinding(
+		key.WithKeys("alt+down"),
+		key.WithHelp("alt+↓", "次のメッセージを選択"),
+	),
+	ForkFromMessage: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "選択したメッセージからフォーク"),
+	),
+	RewindFiles: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "選択したメッセージの前までファイルを巻き戻す"),
+	),
 }
 
 func (m *messagesCmp) Init() tea.Cmd {
@@ -85,6 +107,7 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.session = session.Session{}
 		m.messages = make([]message.Message, 0)
 		m.currentMsgID = ""
+		m.selectedMsgID = ""
 		m.rendering = false
 		return m, nil
 
@@ -95,6 +118,18 @@ func (m *messagesCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport = u
 			cmds = append(cmds, cmd)
 		}
+		switch {
+		case key.Matches(msg, messageKeys.SelectPrevious):
+			m.moveSelection(-1)
+			return m, nil
+		case key.Matches(msg, messageKeys.SelectNext):
+			m.moveSelection(1)
+			return m, nil
+		case key.Matches(msg, messageKeys.ForkFromMessage):
+			return m, m.forkSelected()
+		case key.Matches(msg, messageKeys.RewindFiles):
+			return m, m.rewindSelected()
+		}
 
 	case renderFinishedMsg:
 		m.rendering = false
@@ -240,7 +275,9 @@ func (m *messagesCmp) renderView() {
 	}
 
 	messages := make([]string, 0)
-	for _, v := range m.uiMessages {
+	selectedOffset := -1
+	lines := 0
+	for i, v := range m.uiMessages {
 		messages = append(messages, lipgloss.JoinVertical(lipgloss.Left, v.content),
 			baseStyle.
 				Width(m.width).
@@ -248,6 +285,13 @@ func (m *messagesCmp) renderView() {
 					"",
 				),
 		)
+		lines += lipgloss.Height(v.content) + 1
+		// The marker goes after the last part of the selected message.
+		if v.ID == m.selectedMsgID && (i == len(m.uiMessages)-1 || m.uiMessages[i+1].ID != v.ID) {
+			selectedOffset = lines
+			messages = append(messages, m.selectionMarker())
+			lines += lipgloss.Height(messages[len(messages)-1])
+		}
 	}
 
 	m.viewport.SetContent(
@@ -260,6 +304,100 @@ func (m *messagesCmp) renderView() {
 				),
 			),
 	)
+	if selectedOffset >= 0 && (selectedOffset < m.viewport.YOffset || selectedOffset >= m.viewport.YOffset+m.viewport.Height) {
+		m.viewport.SetYOffset(selectedOffset - m.viewport.Height/2)
+	}
+}
+
+func (m *messagesCmp) selectionMarker() string {
+	t := theme.CurrentTheme()
+	return styles.BaseStyle().
+		Width(m.width).
+		Foreground(t.Accent()).
+		Bold(true).
+		Render(fmt.Sprintf("▲ 選択中 (%s: ここからフォーク, %s: この前までファイルを巻き戻す)", messageKeys.ForkFromMessage.Help().Key, messageKeys.RewindFiles.Help().Key))
+}
+
+// selectableMessages returns the messages that can be selected, which are
+// the ones shown to the user as their own block.
+func (m *messagesCmp) selectableMessages() []message.Message {
+	var selectable []message.Message
+	for _, msg := range m.messages {
+		if msg.Role == message.User || msg.Role == message.Assistant {
+			selectable = append(selectable, msg)
+		}
+	}
+	return selectable
+}
+
+// moveSelection moves the selected message by delta. Moving up from no
+// selection starts at the latest message and moving down past the latest
+// message clears the selection.
+func (m *messagesCmp) moveSelection(delta int) {
+	selectable := m.selectableMessages()
+	if len(selectable) == 0 {
+		return
+	}
+	current := len(selectable)
+	for i, msg := range selectable {
+		if msg.ID == m.selectedMsgID {
+			current = i
+			break
+		}
+	}
+	next := current + delta
+	switch {
+	case next < 0:
+		next = 0
+	case next >= len(selectable):
+		m.selectedMsgID = ""
+		m.renderView()
+		m.viewport.GotoBottom()
+		return
+	}
+	m.selectedMsgID = selectable[next].ID
+	m.renderView()
+}
+
+// forkSelected copies the session up to the selected message into a new
+// session and switches to it.
+func (m *messagesCmp) forkSelected() tea.Cmd {
+	if m.selectedMsgID == "" {
+		return util.ReportWarn(fmt.Sprintf("フォークするメッセージを %s / %s で選択してください", messageKeys.SelectPrevious.Help().Key, messageKeys.SelectNext.Help().Key))
+	}
+	if m.IsAgentWorking() {
+		return util.ReportWarn("エージェントの処理中はフォークできません")
+	}
+	svc := transcript.Services{
+		Sessions: m.app.Sessions,
+		Messages: m.app.Messages,
+		History:  m.app.History,
+	}
+	sessionID, messageID := m.session.ID, m.selectedMsgID
+	return func() tea.Msg {
+		forked, err := transcript.Fork(context.Background(), svc, sessionID, messageID)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return SessionSelectedMsg(forked)
+	}
+}
+
+// rewindSelected asks to rewind the files to their state before the selected
+// message.
+func (m *messagesCmp) rewindSelected() tea.Cmd {
+	if m.selectedMsgID == "" {
+		return util.ReportWarn(fmt.Sprintf("巻き戻すメッセージを %s / %s で選択してください", messageKeys.SelectPrevious.Help().Key, messageKeys.SelectNext.Help().Key))
+	}
+	if m.IsAgentWorking() {
+		return util.ReportWarn("エージェントの処理中は巻き戻せません")
+	}
+	for _, msg := range m.messages {
+		if msg.ID == m.selectedMsgID {
+			return util.CmdHandler(RewindFilesMsg{SessionID: m.session.ID, Message: msg})
+		}
+	}
+	return nil
 }
 
 func (m *messagesCmp) View() string {
@@ -457,6 +595,7 @@ func (m *messagesCmp) SetSession(session session.Session) tea.Cmd {
 		return nil
 	}
 	m.session = session
+	m.selectedMsgID = ""
 	messages, err := m.app.Messages.List(context.Background(), session.ID)
 	if err != nil {
 		return util.ReportError(err)
@@ -479,6 +618,10 @@ func (m *messagesCmp) BindingKeys() []key.Binding {
 		// m.viewport.KeyMap.PageUp,
 		m.viewport.KeyMap.HalfPageUp,
 		m.viewport.KeyMap.HalfPageDown,
+		messageKeys.SelectPrevious,
+		messageKeys.SelectNext,
+		messageKeys.ForkFromMessage,
+		messageKeys.RewindFiles,
 	}
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/cap-ai/cap/internal/llm/models"
 	"github.com/cap-ai/cap/internal/llm/tools"
 	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/task"
 	"github.com/cap-ai/cap/internal/tui/styles"
 	"github.com/cap-ai/cap/internal/tui/theme"
 	"github.com/charmbracelet/lipgloss"
@@ -163,6 +164,12 @@ func renderAssistantMessage(
 				Foreground(t.TextMuted()).
 				Render(fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, "permission denied")),
 			)
+		case message.FinishReasonRejected:
+			info = append(info, baseStyle.
+				Width(width-1).
+				Foreground(t.TextMuted()).
+				Render(fmt.Sprintf(" %s (%s)", models.SupportedModels[msg.Model].Name, "rejected by user")),
+			)
 		}
 	}
 	if content != "" || (finished && finishData.Reason == message.FinishReasonEndTurn) {
@@ -240,6 +247,8 @@ func toolName(name string) string {
 		return "Write"
 	case tools.PatchToolName:
 		return "Patch"
+	case tools.TodoToolName:
+		return "Todo"
 	}
 	return name
 }
@@ -268,6 +277,8 @@ func getToolAction(name string) string {
 		return "Preparing write..."
 	case tools.PatchToolName:
 		return "Preparing patch..."
+	case tools.TodoToolName:
+		return "Updating tasks..."
 	}
 	return "Working..."
 }
@@ -423,6 +434,16 @@ func renderToolParams(paramWidth int, toolCall message.ToolCall) string {
 		json.Unmarshal([]byte(toolCall.Input), &params)
 		filePath := removeWorkingDirPrefix(params.FilePath)
 		return renderParams(paramWidth, filePath)
+	case tools.TodoToolName:
+		var params tools.TodoParams
+		json.Unmarshal([]byte(toolCall.Input), &params)
+		completed := 0
+		for _, item := range params.Todos {
+			if item.Status == string(task.StatusCompleted) {
+				completed++
+			}
+		}
+		return renderParams(paramWidth, fmt.Sprintf("%d/%d completed", completed, len(params.Todos)))
 	default:
 		input := strings.ReplaceAll(toolCall.Input, "\n", " ")
 		params = renderParams(paramWidth, input)
@@ -523,6 +544,8 @@ func renderToolResponse(toolCall message.ToolCall, response message.ToolResult,
 			toMarkdown(resultContent, true, width),
 			t.Background(),
 		)
+	case tools.TodoToolName:
+		return baseStyle.Width(width).Foreground(t.TextMuted()).Render(resultContent)
 	default:
 		resultContent = fmt.Sprintf("```text\n%s\n```", resultContent)
 		return styles.ForceReplaceBackgroundWithLipgloss(
@@ -11,16 +11,20 @@ import (
 	"github.com/cap-ai/cap/internal/history"
 	"github.com/cap-ai/cap/internal/pubsub"
 	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
 	"github.com/cap-ai/cap/internal/tui/styles"
 	"github.com/cap-ai/cap/internal/tui/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 type sidebarCmp struct {
 	width, height int
 	session       session.Session
 	history       history.Service
+	tasks         task.Service
+	taskItems     []task.Task
 	modFiles      map[string]struct {
 		additions int
 		removals  int
@@ -41,6 +45,7 @@ func (m *sidebarCmp) Init() tea.Cmd {
 
 		// Load initial files and calculate diffs
 		m.loadModifiedFiles(ctx)
+		m.loadTasks(ctx)
 
 		// Return a command that will send file events to the Update method
 		return func() tea.Msg {
@@ -57,6 +62,11 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.session = msg
 			ctx := context.Background()
 			m.loadModifiedFiles(ctx)
+			m.loadTasks(ctx)
+		}
+	case pubsub.Event[task.Task]:
+		if msg.Payload.SessionID == m.session.ID {
+			m.loadTasks(context.Background())
 		}
 	case pubsub.Event[session.Session]:
 		if msg.Type == pubsub.UpdatedEvent {
@@ -96,6 +106,8 @@ func (m *sidebarCmp) View() string {
 				" ",
 				m.sessionSection(),
 				" ",
+				m.tasksSection(),
+				" ",
 				lspsConfigured(m.width),
 				" ",
 				m.modifiedFiles(),
@@ -124,6 +136,85 @@ func (m *sidebarCmp) sessionSection() string {
 	)
 }
 
+func (m *sidebarCmp) taskItem(item task.Task) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	var icon string
+	style := baseStyle.Foreground(t.Text())
+	switch item.Status {
+	case task.StatusInProgress:
+		icon = "▶"
+		style = baseStyle.Foreground(t.Primary()).Bold(true)
+	case task.StatusCompleted:
+		icon = "✓"
+		style = baseStyle.Foreground(t.Success())
+	case task.StatusCancelled:
+		icon = "-"
+		style = baseStyle.Foreground(t.TextMuted()).Strikethrough(true)
+	case task.StatusVetoed:
+		icon = "✗"
+		style = baseStyle.Foreground(t.Error()).Strikethrough(true)
+	default:
+		icon = "○"
+	}
+
+	iconStr := style.Strikethrough(false).Render(icon + " ")
+	contentWidth := max(1, m.width-lipgloss.Width(iconStr))
+	content := ansi.Truncate(item.Content, contentWidth, "…")
+
+	return baseStyle.
+		Width(m.width).
+		Render(
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				iconStr,
+				style.Render(content),
+			),
+		)
+}
+
+func (m *sidebarCmp) tasksSection() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	title := baseStyle.
+		Width(m.width).
+		Foreground(t.Primary()).
+		Bold(true).
+		Render("Tasks:")
+
+	if len(m.taskItems) == 0 {
+		return baseStyle.
+			Width(m.width).
+			Render(
+				lipgloss.JoinVertical(
+					lipgloss.Top,
+					title,
+					baseStyle.Width(m.width).Foreground(t.TextMuted()).Render("No tasks"),
+				),
+			)
+	}
+
+	taskViews := make([]string, 0, len(m.taskItems))
+	for _, item := range m.taskItems {
+		taskViews = append(taskViews, m.taskItem(item))
+	}
+
+	return baseStyle.
+		Width(m.width).
+		Render(
+			lipgloss.JoinVertical(
+				lipgloss.Top,
+				title,
+				lipgloss.JoinVertical(
+					lipgloss.Left,
+					taskViews...,
+				),
+			),
+		)
+}
+
 func (m *sidebarCmp) modifiedFile(filePath string, additions, removals int) string {
 	t := theme.CurrentTheme()
 	baseStyle := styles.BaseStyle()
@@ -235,11 +326,24 @@ func (m *sidebarCmp) GetSize() (int, int) {
 	return m.width, m.height
 }
 
-func NewSidebarCmp(session session.Session, history history.Service) tea.Model {
+func NewSidebarCmp(session session.Session, history history.Service, tasks task.Service) tea.Model {
 	return &sidebarCmp{
 		session: session,
 		history: history,
+		tasks:   tasks,
+	}
+}
+
+func (m *sidebarCmp) loadTasks(ctx context.Context) {
+	if m.tasks == nil || m.session.ID == "" {
+		m.taskItems = nil
+		return
+	}
+	tasks, err := m.tasks.List(ctx, m.session.ID)
+	if err != nil {
+		return
 	}
+	m.taskItems = tasks
 }
 
 func (m *sidebarCmp) loadModifiedFiles(ctx context.Context) {
@@ -0,0 +1,232 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/diff"
+	"github.com/cap-ai/cap/internal/history"
+	"github.com/cap-ai/cap/internal/tui/layout"
+	"github.com/cap-ai/cap/internal/tui/styles"
+	"github.com/cap-ai/cap/internal/tui/theme"
+	"github.com/cap-ai/cap/internal/tui/util"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// RewindConfirmedMsg is sent when the user confirms rewinding the files
+type RewindConfirmedMsg struct {
+	SessionID string
+	Changes   []history.Change
+}
+
+// CloseRewindDialogMsg is sent when the rewind dialog is closed
+type CloseRewindDialogMsg struct{}
+
+// RewindDialog interface for the file rewind dialog
+type RewindDialog interface {
+	tea.Model
+	layout.Bindings
+	SetChanges(sessionID string, changes []history.Change)
+}
+
+type rewindDialogCmp struct {
+	sessionID   string
+	changes     []history.Change
+	selectedIdx int
+	width       int
+	height      int
+	diffView    viewport.Model
+}
+
+type rewindKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	RewindOne key.Binding
+	RewindAll key.Binding
+	Escape    key.Binding
+}
+
+var rewindKeys = rewindKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "前のファイル"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "次のファイル"),
+	),
+	RewindOne: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "このファイルだけ巻き戻す"),
+	),
+	RewindAll: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "すべて巻き戻す"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "閉じる"),
+	),
+}
+
+func (r *rewindDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (r *rewindDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, rewindKeys.Up):
+			if r.selectedIdx > 0 {
+				r.selectedIdx--
+				r.diffView.GotoTop()
+			}
+			return r, nil
+		case key.Matches(msg, rewindKeys.Down):
+			if r.selectedIdx < len(r.changes)-1 {
+				r.selectedIdx++
+				r.diffView.GotoTop()
+			}
+			return r, nil
+		case key.Matches(msg, rewindKeys.RewindOne):
+			if len(r.changes) > 0 {
+				return r, util.CmdHandler(RewindConfirmedMsg{
+					SessionID: r.sessionID,
+					Changes:   []history.Change{r.changes[r.selectedIdx]},
+				})
+			}
+			return r, nil
+		case key.Matches(msg, rewindKeys.RewindAll):
+			return r, util.CmdHandler(RewindConfirmedMsg{
+				SessionID: r.sessionID,
+				Changes:   r.changes,
+			})
+		case key.Matches(msg, rewindKeys.Escape):
+			return r, util.CmdHandler(CloseRewindDialogMsg{})
+		default:
+			// Pass other keys to the diff so it can be scrolled
+			vp, cmd := r.diffView.Update(msg)
+			r.diffView = vp
+			return r, cmd
+		}
+	case tea.WindowSizeMsg:
+		r.width = msg.Width
+		r.height = msg.Height
+	}
+	return r, nil
+}
+
+func rewindLabel(change history.Change) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(change.Path, config.WorkingDirectory()), "/")
+	switch {
+	case change.Delete:
+		return "削除 " + path
+	case !change.Exists:
+		return "復元 " + path
+	default:
+		return "変更 " + path
+	}
+}
+
+func (r *rewindDialogCmp) View() string {
+	th := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	if len(r.changes) == 0 {
+		return baseStyle.Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderBackground(th.Background()).
+			BorderForeground(th.TextMuted()).
+			Width(40).
+			Render("No changes to rewind")
+	}
+
+	maxWidth := max(40, int(float64(r.width)*0.8))
+
+	maxVisibleFiles := min(8, len(r.changes))
+	startIdx := 0
+	if r.selectedIdx >= maxVisibleFiles {
+		startIdx = r.selectedIdx - maxVisibleFiles + 1
+	}
+	endIdx := min(startIdx+maxVisibleFiles, len(r.changes))
+
+	fileItems := make([]string, 0, maxVisibleFiles)
+	for i := startIdx; i < endIdx; i++ {
+		itemStyle := baseStyle.Width(maxWidth)
+		if r.changes[i].Delete {
+			itemStyle = itemStyle.Foreground(th.Error())
+		}
+		if i == r.selectedIdx {
+			itemStyle = itemStyle.
+				Background(th.Primary()).
+				Foreground(th.Background()).
+				Bold(true)
+		}
+		fileItems = append(fileItems, itemStyle.Padding(0, 1).Render(ansi.Truncate(rewindLabel(r.changes[i]), maxWidth-2, "…")))
+	}
+
+	title := baseStyle.
+		Foreground(th.Primary()).
+		Bold(true).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render(fmt.Sprintf("Rewind Files (%d)", len(r.changes)))
+
+	help := baseStyle.
+		Foreground(th.TextMuted()).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render("enter: このファイルだけ巻き戻す, a: すべて巻き戻す, esc: 閉じる")
+
+	selected := r.changes[r.selectedIdx]
+	patch, _, _ := diff.GenerateDiff(selected.Current, selected.Target, selected.Path)
+	formatted, err := diff.FormatDiff(patch, diff.WithTotalWidth(maxWidth))
+	if err != nil {
+		formatted = patch
+	}
+	r.diffView.Width = maxWidth
+	r.diffView.Height = max(5, int(float64(r.height)*0.8)-len(fileItems)-8)
+	r.diffView.SetContent(formatted)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		baseStyle.Width(maxWidth).Render(""),
+		baseStyle.Width(maxWidth).Render(lipgloss.JoinVertical(lipgloss.Left, fileItems...)),
+		baseStyle.Width(maxWidth).Render(""),
+		r.diffView.View(),
+		baseStyle.Width(maxWidth).Render(""),
+		help,
+	)
+
+	return baseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(th.Background()).
+		BorderForeground(th.TextMuted()).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (r *rewindDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(rewindKeys)
+}
+
+func (r *rewindDialogCmp) SetChanges(sessionID string, changes []history.Change) {
+	r.sessionID = sessionID
+	r.changes = changes
+	r.selectedIdx = 0
+	r.diffView.GotoTop()
+}
+
+// NewRewindDialogCmp creates a new file rewind dialog
+func NewRewindDialogCmp() RewindDialog {
+	return &rewindDialogCmp{
+		diffView: viewport.New(0, 0),
+	}
+}
@@ -0,0 +1,322 @@
+package dialog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cap-ai/cap/internal/llm/agent"
+	"github.com/cap-ai/cap/internal/message"
+	"github.com/cap-ai/cap/internal/tui/layout"
+	"github.com/cap-ai/cap/internal/tui/styles"
+	"github.com/cap-ai/cap/internal/tui/theme"
+	"github.com/cap-ai/cap/internal/tui/util"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// StepResponseMsg is sent when the user answers the tool calls proposed in leash mode
+type StepResponseMsg struct {
+	SessionID string
+	Decision  agent.StepDecision
+}
+
+// StepDialogCmp interface for the leash mode approval dialog
+type StepDialogCmp interface {
+	tea.Model
+	layout.Bindings
+	SetStep(sessionID string, toolCalls []message.ToolCall)
+	SessionID() string
+}
+
+type stepMode int
+
+const (
+	stepModeReview stepMode = iota
+	stepModeEdit
+	stepModeFeedback
+)
+
+type stepKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Approve  key.Binding
+	Edit     key.Binding
+	Reject   key.Binding
+	Feedback key.Binding
+	Save     key.Binding
+	Escape   key.Binding
+}
+
+var stepKeys = stepKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "前のツール呼び出し"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "次のツール呼び出し"),
+	),
+	Approve: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "承認して実行"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "入力を編集"),
+	),
+	Reject: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "却下"),
+	),
+	Feedback: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "理由を添えて却下"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "確定"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "編集をやめる"),
+	),
+}
+
+type stepDialogCmp struct {
+	width, height int
+	sessionID     string
+	toolCalls     []message.ToolCall
+	edited        map[string]bool
+	selectedIdx   int
+	mode          stepMode
+	textarea      textarea.Model
+	errMsg        string
+}
+
+func (s *stepDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (s *stepDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		return s, nil
+	case tea.KeyMsg:
+		if s.mode != stepModeReview {
+			return s, s.updateInput(msg)
+		}
+		switch {
+		case key.Matches(msg, stepKeys.Up):
+			if s.selectedIdx > 0 {
+				s.selectedIdx--
+			}
+		case key.Matches(msg, stepKeys.Down):
+			if s.selectedIdx < len(s.toolCalls)-1 {
+				s.selectedIdx++
+			}
+		case key.Matches(msg, stepKeys.Approve):
+			return s, s.approve()
+		case key.Matches(msg, stepKeys.Edit):
+			if len(s.toolCalls) > 0 {
+				s.startInput(stepModeEdit, prettyJSON(s.toolCalls[s.selectedIdx].Input))
+				return s, textarea.Blink
+			}
+		case key.Matches(msg, stepKeys.Reject):
+			return s, s.respond(agent.StepDecision{Action: agent.StepReject})
+		case key.Matches(msg, stepKeys.Feedback):
+			s.startInput(stepModeFeedback, "")
+			return s, textarea.Blink
+		}
+	}
+	return s, nil
+}
+
+func (s *stepDialogCmp) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, stepKeys.Escape):
+		s.mode = stepModeReview
+		s.errMsg = ""
+		return nil
+	case key.Matches(msg, stepKeys.Save):
+		value := strings.TrimSpace(s.textarea.Value())
+		if s.mode == stepModeFeedback {
+			return s.respond(agent.StepDecision{Action: agent.StepReject, Feedback: value})
+		}
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(value)); err != nil {
+			s.errMsg = fmt.Sprintf("invalid JSON: %s", err)
+			return nil
+		}
+		call := &s.toolCalls[s.selectedIdx]
+		if call.Input != compacted.String() {
+			call.Input = compacted.String()
+			s.edited[call.ID] = true
+		}
+		s.mode = stepModeReview
+		s.errMsg = ""
+		return nil
+	}
+	var cmd tea.Cmd
+	s.textarea, cmd = s.textarea.Update(msg)
+	return cmd
+}
+
+func (s *stepDialogCmp) startInput(mode stepMode, value string) {
+	t := theme.CurrentTheme()
+	bgColor := t.Background()
+	ta := textarea.New()
+	ta.BlurredStyle.Base = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.FocusedStyle.Base = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.FocusedStyle.CursorLine = styles.BaseStyle().Background(bgColor)
+	ta.FocusedStyle.Placeholder = styles.BaseStyle().Background(bgColor).Foreground(t.TextMuted())
+	ta.FocusedStyle.Text = styles.BaseStyle().Background(bgColor).Foreground(t.Text())
+	ta.Prompt = " "
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetWidth(s.contentWidth())
+	ta.SetHeight(10)
+	if mode == stepModeFeedback {
+		ta.Placeholder = "却下の理由やエージェントへの指示"
+	}
+	ta.SetValue(value)
+	ta.Focus()
+
+	s.textarea = ta
+	s.mode = mode
+	s.errMsg = ""
+}
+
+func (s *stepDialogCmp) approve() tea.Cmd {
+	if len(s.edited) == 0 {
+		return s.respond(agent.StepDecision{Action: agent.StepApprove})
+	}
+	var edited []message.ToolCall
+	for _, call := range s.toolCalls {
+		if s.edited[call.ID] {
+			edited = append(edited, call)
+		}
+	}
+	return s.respond(agent.StepDecision{Action: agent.StepEdit, ToolCalls: edited})
+}
+
+func (s *stepDialogCmp) respond(decision agent.StepDecision) tea.Cmd {
+	s.mode = stepModeReview
+	return util.CmdHandler(StepResponseMsg{SessionID: s.sessionID, Decision: decision})
+}
+
+func (s *stepDialogCmp) contentWidth() int {
+	return max(40, min(100, s.width-20))
+}
+
+func prettyJSON(input string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(input), "", "  "); err != nil {
+		return input
+	}
+	return out.String()
+}
+
+func (s *stepDialogCmp) View() string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+	width := s.contentWidth()
+
+	title := baseStyle.
+		Foreground(t.Primary()).
+		Bold(true).
+		Width(width).
+		Render("Leash: ツール実行の承認")
+
+	items := make([]string, 0, len(s.toolCalls))
+	for i, call := range s.toolCalls {
+		label := fmt.Sprintf("%d. %s", i+1, call.Name)
+		if s.edited[call.ID] {
+			label += " (edited)"
+		}
+		itemStyle := baseStyle.Width(width)
+		if i == s.selectedIdx {
+			itemStyle = itemStyle.
+				Background(t.Primary()).
+				Foreground(t.Background()).
+				Bold(true)
+		}
+		items = append(items, itemStyle.Padding(0, 1).Render(label))
+	}
+
+	var body string
+	switch s.mode {
+	case stepModeReview:
+		input := ""
+		if len(s.toolCalls) > 0 {
+			input = prettyJSON(s.toolCalls[s.selectedIdx].Input)
+		}
+		lines := strings.Split(input, "\n")
+		if len(lines) > 15 {
+			lines = append(lines[:15], "...")
+		}
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, width-2, "…")
+		}
+		body = baseStyle.
+			Width(width).
+			Foreground(t.TextMuted()).
+			Padding(0, 1).
+			Render(strings.Join(lines, "\n"))
+	default:
+		body = s.textarea.View()
+	}
+
+	help := "a: 承認して実行  e: 入力を編集  r: 却下  f: 理由を添えて却下"
+	if s.mode != stepModeReview {
+		help = "ctrl+s: 確定  esc: 戻る"
+	}
+	parts := []string{
+		title,
+		baseStyle.Width(width).Render(""),
+		baseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, items...)),
+		baseStyle.Width(width).Render(""),
+		body,
+		baseStyle.Width(width).Render(""),
+	}
+	if s.errMsg != "" {
+		parts = append(parts, baseStyle.Width(width).Foreground(t.Error()).Render(s.errMsg))
+	}
+	parts = append(parts, baseStyle.Width(width).Foreground(t.TextMuted()).Render(help))
+
+	return baseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(t.Background()).
+		BorderForeground(t.Warning()).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (s *stepDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(stepKeys)
+}
+
+func (s *stepDialogCmp) SetStep(sessionID string, toolCalls []message.ToolCall) {
+	s.sessionID = sessionID
+	s.toolCalls = append([]message.ToolCall(nil), toolCalls...)
+	s.edited = make(map[string]bool)
+	s.selectedIdx = 0
+	s.mode = stepModeReview
+	s.errMsg = ""
+}
+
+func (s *stepDialogCmp) SessionID() string {
+	return s.sessionID
+}
+
+// NewStepDialogCmp creates a new leash mode approval dialog
+func NewStepDialogCmp() StepDialogCmp {
+	return &stepDialogCmp{
+		edited: make(map[string]bool),
+	}
+}
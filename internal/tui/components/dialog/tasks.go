@@ -0,0 +1,216 @@
+package dialog
+
+import (
+	"github.com/cap-ai/cap/internal/task"
+	"github.com/cap-ai/cap/internal/tui/layout"
+	"github.com/cap-ai/cap/internal/tui/styles"
+	"github.com/cap-ai/cap/internal/tui/theme"
+	"github.com/cap-ai/cap/internal/tui/util"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TaskVetoToggledMsg is sent when the user vetoes or restores a task
+type TaskVetoToggledMsg struct {
+	Task task.Task
+}
+
+// CloseTaskDialogMsg is sent when the task dialog is closed
+type CloseTaskDialogMsg struct{}
+
+// TaskDialog interface for the plan review dialog
+type TaskDialog interface {
+	tea.Model
+	layout.Bindings
+	SetTasks(tasks []task.Task)
+}
+
+type taskDialogCmp struct {
+	tasks       []task.Task
+	selectedIdx int
+	width       int
+	height      int
+}
+
+type taskKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Veto   key.Binding
+	Escape key.Binding
+	J      key.Binding
+	K      key.Binding
+}
+
+var taskKeys = taskKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up"),
+		key.WithHelp("↑", "前のタスク"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "次のタスク"),
+	),
+	Veto: key.NewBinding(
+		key.WithKeys(" ", "enter", "x"),
+		key.WithHelp("space/x", "拒否・取り消し"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "閉じる"),
+	),
+	J: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "次のタスク"),
+	),
+	K: key.NewBinding(
+		key.WithKeys("k"),
+		key.WithHelp("k", "前のタスク"),
+	),
+}
+
+func (t *taskDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (t *taskDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, taskKeys.Up) || key.Matches(msg, taskKeys.K):
+			if t.selectedIdx > 0 {
+				t.selectedIdx--
+			}
+			return t, nil
+		case key.Matches(msg, taskKeys.Down) || key.Matches(msg, taskKeys.J):
+			if t.selectedIdx < len(t.tasks)-1 {
+				t.selectedIdx++
+			}
+			return t, nil
+		case key.Matches(msg, taskKeys.Veto):
+			if len(t.tasks) > 0 {
+				return t, util.CmdHandler(TaskVetoToggledMsg{
+					Task: t.tasks[t.selectedIdx],
+				})
+			}
+		case key.Matches(msg, taskKeys.Escape):
+			return t, util.CmdHandler(CloseTaskDialogMsg{})
+		}
+	case tea.WindowSizeMsg:
+		t.width = msg.Width
+		t.height = msg.Height
+	}
+	return t, nil
+}
+
+func taskStatusLabel(status task.Status) string {
+	switch status {
+	case task.StatusInProgress:
+		return "▶"
+	case task.StatusCompleted:
+		return "✓"
+	case task.StatusCancelled:
+		return "-"
+	case task.StatusVetoed:
+		return "✗"
+	default:
+		return "○"
+	}
+}
+
+func (t *taskDialogCmp) View() string {
+	th := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle()
+
+	if len(t.tasks) == 0 {
+		return baseStyle.Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderBackground(th.Background()).
+			BorderForeground(th.TextMuted()).
+			Width(40).
+			Render("No tasks available")
+	}
+
+	maxWidth := max(40, min(80, t.width-15))
+
+	// Limit height to avoid taking up too much screen space
+	maxVisibleTasks := min(10, len(t.tasks))
+	startIdx := 0
+	if len(t.tasks) > maxVisibleTasks {
+		halfVisible := maxVisibleTasks / 2
+		if t.selectedIdx >= halfVisible && t.selectedIdx < len(t.tasks)-halfVisible {
+			startIdx = t.selectedIdx - halfVisible
+		} else if t.selectedIdx >= len(t.tasks)-halfVisible {
+			startIdx = len(t.tasks) - maxVisibleTasks
+		}
+	}
+	endIdx := min(startIdx+maxVisibleTasks, len(t.tasks))
+
+	taskItems := make([]string, 0, maxVisibleTasks)
+	for i := startIdx; i < endIdx; i++ {
+		item := t.tasks[i]
+		itemStyle := baseStyle.Width(maxWidth)
+		switch item.Status {
+		case task.StatusVetoed:
+			itemStyle = itemStyle.Foreground(th.Error())
+		case task.StatusCompleted, task.StatusCancelled:
+			itemStyle = itemStyle.Foreground(th.TextMuted())
+		}
+		if i == t.selectedIdx {
+			itemStyle = itemStyle.
+				Background(th.Primary()).
+				Foreground(th.Background()).
+				Bold(true)
+		}
+		label := taskStatusLabel(item.Status) + " " + item.Content
+		taskItems = append(taskItems, itemStyle.Padding(0, 1).Render(ansi.Truncate(label, maxWidth-2, "…")))
+	}
+
+	title := baseStyle.
+		Foreground(th.Primary()).
+		Bold(true).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render("Review Plan")
+
+	help := baseStyle.
+		Foreground(th.TextMuted()).
+		Width(maxWidth).
+		Padding(0, 1).
+		Render("space/x: 実行前のタスクを拒否・取り消し")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		baseStyle.Width(maxWidth).Render(""),
+		baseStyle.Width(maxWidth).Render(lipgloss.JoinVertical(lipgloss.Left, taskItems...)),
+		baseStyle.Width(maxWidth).Render(""),
+		help,
+	)
+
+	return baseStyle.Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderBackground(th.Background()).
+		BorderForeground(th.TextMuted()).
+		Width(lipgloss.Width(content) + 4).
+		Render(content)
+}
+
+func (t *taskDialogCmp) BindingKeys() []key.Binding {
+	return layout.KeyMapToSlice(taskKeys)
+}
+
+func (t *taskDialogCmp) SetTasks(tasks []task.Task) {
+	t.tasks = tasks
+	if t.selectedIdx >= len(tasks) {
+		t.selectedIdx = max(0, len(tasks)-1)
+	}
+}
+
+// NewTaskDialogCmp creates a new plan review dialog
+func NewTaskDialogCmp() TaskDialog {
+	return &taskDialogCmp{
+		tasks: []task.Task{},
+	}
+}
@@ -7,11 +7,13 @@ import (
 
 	"github.com/cap-ai/cap/internal/app"
 	"github.com/cap-ai/cap/internal/config"
+	"github.com/cap-ai/cap/internal/history"
 	"github.com/cap-ai/cap/internal/llm/agent"
 	"github.com/cap-ai/cap/internal/logging"
 	"github.com/cap-ai/cap/internal/permission"
 	"github.com/cap-ai/cap/internal/pubsub"
 	"github.com/cap-ai/cap/internal/session"
+	"github.com/cap-ai/cap/internal/task"
 	"github.com/cap-ai/cap/internal/tui/components/chat"
 	"github.com/cap-ai/cap/internal/tui/components/core"
 	"github.com/cap-ai/cap/internal/tui/components/dialog"
@@ -37,6 +39,10 @@ type keyMap struct {
 
 type startCompactSessionMsg struct{}
 
+type showTaskDialogMsg struct{}
+
+type toggleLeashMsg struct{}
+
 const (
 	quitKey = "q"
 )
@@ -175,6 +181,15 @@ type appModel struct {
 	showMultiArgumentsDialog bool
 	multiArgumentsDialog     dialog.MultiArgumentsDialogCmp
 
+	showTaskDialog bool
+	taskDialog     dialog.TaskDialog
+
+	showRewindDialog bool
+	rewindDialog     dialog.RewindDialog
+
+	showStepDialog bool
+	stepDialog     dialog.StepDialogCmp
+
 	isCompacting      bool
 	compactingMessage string
 }
@@ -251,6 +266,18 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.filepicker = filepicker.(dialog.FilepickerCmp)
 		cmds = append(cmds, filepickerCmd)
 
+		tasks, tasksCmd := a.taskDialog.Update(msg)
+		a.taskDialog = tasks.(dialog.TaskDialog)
+		cmds = append(cmds, tasksCmd)
+
+		rewind, rewindCmd := a.rewindDialog.Update(msg)
+		a.rewindDialog = rewind.(dialog.RewindDialog)
+		cmds = append(cmds, rewindCmd)
+
+		step, stepCmd := a.stepDialog.Update(msg)
+		a.stepDialog = step.(dialog.StepDialogCmp)
+		cmds = append(cmds, stepCmd)
+
 		a.initDialog.SetSize(msg.Width, msg.Height)
 
 		if a.showMultiArgumentsDialog {
@@ -342,6 +369,88 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.showCommandDialog = false
 		return a, nil
 
+	case showTaskDialogMsg:
+		if a.selectedSession.ID == "" {
+			return a, util.ReportWarn("No active session")
+		}
+		tasks, err := a.app.Tasks.List(context.Background(), a.selectedSession.ID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		if len(tasks) == 0 {
+			return a, util.ReportWarn("No tasks in this session")
+		}
+		a.taskDialog.SetTasks(tasks)
+		a.showTaskDialog = true
+		return a, nil
+
+	case dialog.CloseTaskDialogMsg:
+		a.showTaskDialog = false
+		return a, nil
+
+	case dialog.TaskVetoToggledMsg:
+		ctx := context.Background()
+		updated, err := a.app.Tasks.ToggleVeto(ctx, msg.Task.ID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		tasks, err := a.app.Tasks.List(ctx, updated.SessionID)
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		a.taskDialog.SetTasks(tasks)
+		if updated.Status == task.StatusVetoed {
+			return a, util.ReportInfo("Task vetoed: " + updated.Content)
+		}
+		return a, util.ReportInfo("Task restored: " + updated.Content)
+
+	case chat.RewindFilesMsg:
+		changes, err := history.PlanRewind(context.Background(), a.app.History, msg.SessionID, msg.Message.CreatedAt, "")
+		if err != nil {
+			return a, util.ReportError(err)
+		}
+		if len(changes) == 0 {
+			return a, util.ReportInfo("No file changes to rewind")
+		}
+		a.rewindDialog.SetChanges(msg.SessionID, changes)
+		a.showRewindDialog = true
+		return a, nil
+
+	case dialog.CloseRewindDialogMsg:
+		a.showRewindDialog = false
+		return a, nil
+
+	case dialog.RewindConfirmedMsg:
+		a.showRewindDialog = false
+		if err := history.ApplyRewind(context.Background(), a.app.History, msg.SessionID, msg.Changes); err != nil {
+			return a, util.ReportError(err)
+		}
+		return a, util.ReportInfo(fmt.Sprintf("Rewound %d file(s)", len(msg.Changes)))
+
+	case toggleLeashMsg:
+		if a.selectedSession.ID == "" {
+			return a, util.ReportWarn("No active session")
+		}
+		leashed := !a.app.CoderAgent.IsLeashed(a.selectedSession.ID)
+		a.app.CoderAgent.SetLeash(a.selectedSession.ID, leashed)
+		if leashed {
+			return a, util.ReportInfo("Leash mode enabled")
+		}
+		return a, util.ReportInfo("Leash mode disabled")
+
+	case dialog.StepResponseMsg:
+		a.showStepDialog = false
+		a.app.CoderAgent.RespondStep(msg.SessionID, msg.Decision)
+		return a, nil
+
+	case pubsub.Event[task.Task]:
+		// Keep the dialog in sync while the agent updates the plan
+		if a.showTaskDialog && msg.Payload.SessionID == a.selectedSession.ID {
+			if tasks, err := a.app.Tasks.List(context.Background(), a.selectedSession.ID); err == nil {
+				a.taskDialog.SetTasks(tasks)
+			}
+		}
+
 	case startCompactSessionMsg:
 		// Start compacting the current session
 		a.isCompacting = true
@@ -361,6 +470,15 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case pubsub.Event[agent.AgentEvent]:
 		payload := msg.Payload
+		if payload.Type == agent.AgentEventTypeStepApproval {
+			a.stepDialog.SetStep(payload.SessionID, payload.ToolCalls)
+			a.showStepDialog = true
+			return a, nil
+		}
+		if a.showStepDialog && payload.Type != agent.AgentEventTypeSummarize {
+			// The request finished or was cancelled while waiting for approval
+			a.showStepDialog = false
+		}
 		if payload.Error != nil {
 			a.isCompacting = false
 			return a, util.ReportError(payload.Error)
@@ -512,6 +630,12 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if a.showMultiArgumentsDialog {
 				a.showMultiArgumentsDialog = false
 			}
+			if a.showTaskDialog {
+				a.showTaskDialog = false
+			}
+			if a.showRewindDialog {
+				a.showRewindDialog = false
+			}
 			return a, nil
 		case key.Matches(msg, keys.SwitchSession):
 			if a.currentPage == page.ChatPage && !a.showQuit && !a.showPermissions && !a.showCommandDialog {
@@ -695,6 +819,36 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if a.showStepDialog {
+		d, stepCmd := a.stepDialog.Update(msg)
+		a.stepDialog = d.(dialog.StepDialogCmp)
+		cmds = append(cmds, stepCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showTaskDialog {
+		d, taskCmd := a.taskDialog.Update(msg)
+		a.taskDialog = d.(dialog.TaskDialog)
+		cmds = append(cmds, taskCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
+	if a.showRewindDialog {
+		d, rewindCmd := a.rewindDialog.Update(msg)
+		a.rewindDialog = d.(dialog.RewindDialog)
+		cmds = append(cmds, rewindCmd)
+		// Only block key messages send all other messages down
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return a, tea.Batch(cmds...)
+		}
+	}
+
 	s, _ := a.status.Update(msg)
 	a.status = s.(core.StatusCmp)
 	a.pages[a.currentPage], cmd = a.pages[a.currentPage].Update(msg)
@@ -919,6 +1073,51 @@ func (a appModel) View() string {
 		)
 	}
 
+	if a.showStepDialog {
+		overlay := a.stepDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showTaskDialog {
+		overlay := a.taskDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
+	if a.showRewindDialog {
+		overlay := a.rewindDialog.View()
+		row := lipgloss.Height(appView) / 2
+		row -= lipgloss.Height(overlay) / 2
+		col := lipgloss.Width(appView) / 2
+		col -= lipgloss.Width(overlay) / 2
+		appView = layout.PlaceOverlay(
+			col,
+			row,
+			overlay,
+			appView,
+			true,
+		)
+	}
+
 	if a.showMultiArgumentsDialog {
 		overlay := a.multiArgumentsDialog.View()
 		row := lipgloss.Height(appView) / 2
@@ -951,6 +1150,9 @@ func New(app *app.App) tea.Model {
 		permissions:   dialog.NewPermissionDialogCmp(),
 		initDialog:    dialog.NewInitDialogCmp(),
 		themeDialog:   dialog.NewThemeDialogCmp(),
+		taskDialog:    dialog.NewTaskDialogCmp(),
+		rewindDialog:  dialog.NewRewindDialogCmp(),
+		stepDialog:    dialog.NewStepDialogCmp(),
 		app:           app,
 		commands:      []dialog.Command{},
 		pages: map[page.PageID]tea.Model{
@@ -992,6 +1194,24 @@ If there are Cursor rules (in .cursor/rules/ or .cursorrules) or Copilot rules (
 			}
 		},
 	})
+
+	model.RegisterCommand(dialog.Command{
+		ID:          "tasks",
+		Title:       "Review Plan",
+		Description: "エージェントのタスク一覧を確認し、実行前のステップを拒否します。",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(showTaskDialogMsg{})
+		},
+	})
+
+	model.RegisterCommand(dialog.Command{
+		ID:          "leash",
+		Title:       "Toggle Leash Mode",
+		Description: "ツールを実行する前に毎回承認・編集・却下を求める手綱モードを、このセッションで切り替えます。",
+		Handler: func(cmd dialog.Command) tea.Cmd {
+			return util.CmdHandler(toggleLeashMsg{})
+		},
+	})
 	// Load custom commands
 	customCommands, err := dialog.LoadCustomCommands()
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/cap-ai/cap/cmd"
+	"github.com/cap-ai/cap/internal/config"
 	"github.com/cap-ai/cap/internal/llm/models"
 	"github.com/cap-ai/cap/internal/logging"
 )
@@ -143,6 +144,13 @@ func initialize(print bool) {
 }
 
 func showModels() {
+	// Loading the config adds the models declared in it and the ones
+	// discovered on local servers.
+	if cwd, err := os.Getwd(); err == nil {
+		if _, err := config.Load(cwd, false); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
 	modelList := []string{}
 	for modelID := range models.SupportedModels {
 		modelList = append(modelList, string(modelID))